@@ -0,0 +1,97 @@
+package beanstream
+
+import (
+	"context"
+	"fmt"
+)
+
+// AdjustmentType is the kind of change an Adjustment made to a transaction.
+// It is one of the TransactionTypes that adjust another transaction.
+type AdjustmentType = TransactionType
+
+const (
+	AdjustmentReturn       = TransactionReturn
+	AdjustmentVoidPurchase = TransactionVoidPurchase
+	AdjustmentVoidReturn   = TransactionVoidReturn
+	AdjustmentCompletion   = TransactionCompletion
+)
+
+// IsApproved reports whether the gateway accepted the adjustment.
+func (a Adjustment) IsApproved() bool {
+	return a.Approval == StatusApproved
+}
+
+// approvedAdjustments returns the approved adjustments of type typ.
+func (t *Transaction) approvedAdjustments(typ AdjustmentType) []Adjustment {
+	var found []Adjustment
+	for _, adj := range t.Adjustments {
+		if adj.Type == typ && adj.IsApproved() {
+			found = append(found, adj)
+		}
+	}
+	return found
+}
+
+// Voided reports whether the transaction was voided.
+func (t *Transaction) Voided() bool {
+	return len(t.approvedAdjustments(AdjustmentVoidPurchase)) > 0
+}
+
+/*
+Captured is the amount actually charged to the customer: the amount of a
+purchase, or what has been completed of a pre-authorization. It is zero once
+the transaction has been voided.
+*/
+func (t *Transaction) Captured() Money {
+	if t.Voided() {
+		return 0
+	}
+	if t.Type == TransactionPreAuth {
+		return t.TotalCompletions
+	}
+	return t.Amount
+}
+
+// NetCaptured is Captured less what has been returned to the customer.
+func (t *Transaction) NetCaptured() Money {
+	return t.Captured() - t.TotalRefunds
+}
+
+// Refundable is how much can still be returned with ReturnPayment.
+func (t *Transaction) Refundable() Money {
+	if !t.IsApproved() {
+		return 0
+	}
+	if net := t.NetCaptured(); net > 0 {
+		return net
+	}
+	return 0
+}
+
+/*
+Voidable reports whether VoidPayment may still cancel the transaction: it was
+approved and has not already been voided, returned or completed. The gateway
+also refuses voids once the day's batch has settled, which cannot be told from
+the transaction, so a Voidable transaction can still fail to void.
+
+A pre-authorization is never Voidable: the gateway refuses to void one, and it
+is released by completing it for 0 instead (see PreAuth.Release).
+*/
+func (t *Transaction) Voidable() bool {
+	if !t.IsApproved() || t.Voided() || t.Type == TransactionPreAuth {
+		return false
+	}
+	return len(t.approvedAdjustments(AdjustmentReturn)) == 0 && len(t.approvedAdjustments(AdjustmentCompletion)) == 0
+}
+
+// checkRefundable refuses a return of more than is left to refund on transId.
+func (api PaymentsAPI) checkRefundable(ctx context.Context, transId string, amount Money) error {
+	tx, err := api.GetTransactionContext(ctx, transId)
+	if err != nil {
+		return err
+	}
+	if left := tx.Refundable(); amount > left {
+		return &BeanstreamApiException{400, 0, 0, fmt.Sprintf("cannot return %v of transaction %v, %v is refundable", amount, transId, left), "", nil, nil}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func decodeTransaction(t *testing.T, body string) *Transaction {
+	tx := &Transaction{}
+	assert.Nil(t, json.Unmarshal([]byte(body), tx))
+	return tx
+}
+
+func TestUnit_Transaction_Refundable(t *testing.T) {
+	tx := decodeTransaction(t, `{"id":1,"approved":1,"type":"P","amount":50.00,"total_refunds":12.50,
+		"adjusted_by":[{"id":2,"type":"R","approval":1,"amount":12.50},{"id":3,"type":"R","approval":0,"amount":99.00}]}`)
+	assert.Equal(t, AdjustmentReturn, tx.Adjustments[0].Type)
+	assert.True(t, tx.Adjustments[0].IsApproved())
+	assert.False(t, tx.Adjustments[1].IsApproved())
+	assert.Equal(t, MustParseMoney("50.00"), tx.Captured())
+	assert.Equal(t, MustParseMoney("37.50"), tx.NetCaptured())
+	assert.Equal(t, MustParseMoney("37.50"), tx.Refundable())
+	assert.False(t, tx.Voidable())
+}
+
+func TestUnit_Transaction_PreAuthCaptured(t *testing.T) {
+	tx := decodeTransaction(t, `{"id":1,"approved":1,"type":"PA","amount":100.00,"total_completions":60.00,
+		"adjusted_by":[{"id":2,"type":"PAC","approval":1,"amount":60.00}]}`)
+	assert.Equal(t, MustParseMoney("60.00"), tx.Captured())
+	assert.Equal(t, MustParseMoney("60.00"), tx.Refundable())
+	assert.False(t, tx.Voidable())
+}
+
+func TestUnit_Transaction_PreAuthNotVoidable(t *testing.T) {
+	tx := decodeTransaction(t, `{"id":1,"approved":1,"type":"PA","amount":100.00}`)
+	assert.True(t, tx.IsApproved())
+	assert.False(t, tx.Voidable())
+}
+
+func TestUnit_Transaction_Voided(t *testing.T) {
+	tx := decodeTransaction(t, `{"id":1,"approved":1,"type":"P","amount":20.00}`)
+	assert.True(t, tx.Voidable())
+	tx = decodeTransaction(t, `{"id":1,"approved":1,"type":"P","amount":20.00,"adjusted_by":[{"id":2,"type":"VP","approval":1,"amount":20.00}]}`)
+	assert.True(t, tx.Voided())
+	assert.False(t, tx.Voidable())
+	assert.Equal(t, Money(0), tx.Refundable())
+	tx = decodeTransaction(t, `{"id":1,"approved":0,"type":"P","amount":20.00}`)
+	assert.Equal(t, Money(0), tx.Refundable())
+	assert.False(t, tx.Voidable())
+}
+
+func TestUnit_Payments_CheckReturns(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodGet, "/payments/10000001", 200, `{"id":10000001,"approved":1,"type":"P","amount":50.00,"total_refunds":40.00}`).
+		on(http.MethodPost, "/returns", 200, `{"id":"10000002","approved":"1","type":"R"}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), CheckReturns: true}
+
+	_, err := gateway.Payments().ReturnPayment("10000001", MustParseMoney("10.01"))
+	assert.Equal(t, 400, err.(*BeanstreamApiException).Status)
+	assert.Equal(t, 0, fake.count(http.MethodPost, "/returns"))
+
+	_, err = gateway.Payments().ReturnPayment("10000001", MustParseMoney("10.00"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, fake.count(http.MethodPost, "/returns"))
+
+	// without CheckReturns the gateway decides
+	gateway.CheckReturns = false
+	_, err = gateway.Payments().ReturnPayment("10000001", MustParseMoney("99.00"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, fake.count(http.MethodPost, "/returns"))
+}
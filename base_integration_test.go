@@ -8,22 +8,22 @@ import (
 
 func createGateway() Gateway {
 	config := Config{
-		"300200578",
-		"4BaD82D9197b4cc4b70a221911eE9f70",
-		"D97D3BE1EE964A6193D17A571D9FBC80",
-		"4e6Ff318bee64EA391609de89aD4CF5d",
-		"www",
-		"api",
-		"v1",
-		"-8:00"}
-	return Gateway{config}
+		MerchantId:      "300200578",
+		PaymentsApiKey:  "4BaD82D9197b4cc4b70a221911eE9f70",
+		ProfilesApiKey:  "D97D3BE1EE964A6193D17A571D9FBC80",
+		ReportingApiKey: "4e6Ff318bee64EA391609de89aD4CF5d",
+		UrlPrefix:       "www",
+		UrlApi:          "api",
+		UrlApiVersion:   "v1",
+		TimezoneOffset:  "-8:00"}
+	return Gateway{Config: config}
 }
 
 func createCardRequest() PaymentRequest {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.CARD,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        12.99,
+		Amount:        MustParseMoney("12.99"),
 		Card: CreditCard{
 			Name:        "John Doe",
 			Number:      "5100000010001004",
@@ -1,6 +1,8 @@
 package beanstream
 
-import ()
+import (
+	"net/http"
+)
 
 /*
 Gateway is the entry point for making payments. It stores the configuration
@@ -11,28 +13,72 @@ Each time you call one of those APIs you get a new API object. It is recommended
 that you always call these methods if you are going to process payments in a
 multi-threaded environment using go routines. Do not share them across threads if
 possible.
+
+All of the APIs share the Gateway's HTTPClient, so connections to Beanstream are
+kept alive and reused between calls. Leave it nil to use a shared client with a
+60 second timeout. To use a proxy, custom TLS roots or a stand-in server in tests,
+supply your own client and set its Transport. Redirects are never followed,
+since a 302 from the gateway is a RedirectException, unless your client has a
+CheckRedirect of its own.
+
+Retry sets how calls that only read data are retried when the connection fails
+or the gateway has an internal error. By default they are not retried; see
+RetryPolicy.
+
+Logger, if set, receives every request and response with card data and
+passcodes masked. The SDK itself never prints anything.
+
+Interceptors wrap every call for cross-cutting work such as correlation IDs,
+metrics or auditing; see Interceptor.
+
+CaptureResponses keeps the gateway's raw response, its status, headers, body,
+duration and request ID, in the Response field of results and of
+*BeanstreamApiException; see ResponseInfo.
+
+CheckReturns makes ReturnPayment look the transaction up first and refuse to
+return more than is left to refund; see Transaction.Refundable.
+
+Verification voids approved card payments whose AVS or CVD results fail your
+risk rules; see VerificationPolicy.
+
+CheckCards runs the cardValidation package on card numbers, CVDs and expiry
+dates before MakePayment, CreateProfile or AddCard sends them, so a mistyped
+card is refused without a round trip to the gateway.
 */
 type Gateway struct {
-	Config Config
+	Config           Config
+	HTTPClient       *http.Client
+	Retry            RetryPolicy
+	Logger           Logger
+	Interceptors     []Interceptor
+	CaptureResponses bool
+	CheckReturns     bool
+	Verification     VerificationPolicy
+	CheckCards       bool
 }
 
-//Payments returns a new beanstream.PaymentsAPI type struct with the config set.
+// Payments returns a new beanstream.PaymentsAPI type struct with the config set.
 func (v *Gateway) Payments() PaymentsAPI {
-	api := PaymentsAPI{v.Config}
+	api := PaymentsAPI{v.Config, v.transport(), v.CheckReturns, v.Verification, v.CheckCards}
 
 	return api
 }
 
-//Profiles returns a new beanstream.ProfilesAPI type struct with the config set.
+// Profiles returns a new beanstream.ProfilesAPI type struct with the config set.
 func (v *Gateway) Profiles() ProfilesAPI {
-	api := ProfilesAPI{v.Config}
+	api := ProfilesAPI{v.Config, v.transport(), v.CheckCards}
 
 	return api
 }
 
-//Reports returns a new beanstream.ReportsAPI type struct with the config set.
+// Reports returns a new beanstream.ReportsAPI type struct with the config set.
 func (v *Gateway) Reports() ReportsAPI {
-	api := ReportsAPI{v.Config}
+	api := ReportsAPI{v.Config, v.transport()}
 
 	return api
 }
+
+// transport returns the request settings every API created by this gateway inherits.
+func (v *Gateway) transport() transport {
+	return transport{client: v.HTTPClient, retry: v.Retry, logger: v.Logger, interceptors: v.Interceptors, capture: v.CaptureResponses}
+}
@@ -0,0 +1,226 @@
+package cardValidation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Brand is a card brand, as the gateway's two letter card type code.
+type Brand string
+
+const (
+	Unknown    Brand = ""
+	Visa       Brand = "VI"
+	Mastercard Brand = "MC"
+	Amex       Brand = "AM"
+	Discover   Brand = "NN"
+	Diners     Brand = "DI"
+	JCB        Brand = "JB"
+)
+
+// brandRule is how to recognize a brand from the leading digits of a number,
+// and the number and CVD lengths it uses.
+type brandRule struct {
+	brand     Brand
+	from, to  int // inclusive range of the number's first len(from) digits
+	lengths   []int
+	cvdLength int
+}
+
+// brandRules is searched in order, so a narrow range must come before a
+// wider one that contains it.
+var brandRules = []brandRule{
+	{Amex, 34, 34, []int{15}, 4},
+	{Amex, 37, 37, []int{15}, 4},
+	{Diners, 300, 305, []int{14, 15, 16, 17, 18, 19}, 3},
+	{Diners, 36, 36, []int{14, 15, 16, 17, 18, 19}, 3},
+	{Diners, 38, 39, []int{14, 15, 16, 17, 18, 19}, 3},
+	{JCB, 3528, 3589, []int{16, 17, 18, 19}, 3},
+	{Visa, 4, 4, []int{13, 16, 19}, 3},
+	{Mastercard, 51, 55, []int{16}, 3},
+	{Mastercard, 2221, 2720, []int{16}, 3},
+	{Discover, 6011, 6011, []int{16, 17, 18, 19}, 3},
+	{Discover, 622126, 622925, []int{16, 17, 18, 19}, 3},
+	{Discover, 644, 649, []int{16, 17, 18, 19}, 3},
+	{Discover, 65, 65, []int{16, 17, 18, 19}, 3},
+}
+
+func (r brandRule) matches(number string) bool {
+	digits := len(strconv.Itoa(r.from))
+	if len(number) < digits {
+		return false
+	}
+	prefix, err := strconv.Atoi(number[:digits])
+	return err == nil && prefix >= r.from && prefix <= r.to
+}
+
+func ruleFor(number string) (brandRule, bool) {
+	for _, r := range brandRules {
+		if r.matches(number) {
+			return r, true
+		}
+	}
+	return brandRule{}, false
+}
+
+// Normalize removes the spaces and dashes people type into card numbers.
+func Normalize(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// DetectBrand finds the brand of a card from the leading digits of its number.
+func DetectBrand(number string) Brand {
+	r, _ := ruleFor(Normalize(number))
+	return r.brand
+}
+
+// Luhn reports whether number, which must be all digits, passes the Luhn
+// (mod 10) check that every card number is built to pass.
+func Luhn(number string) bool {
+	if number == "" {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Error is a problem with one field of a card, named as the gateway names it.
+type Error struct {
+	Field   string
+	Message string
+}
+
+func (e Error) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Errors is every problem Validate found.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid card: " + strings.Join(msgs, "; ")
+}
+
+// Card is the card data to validate.
+type Card struct {
+	Number      string
+	ExpiryMonth string // 1 to 12
+	ExpiryYear  string // 2 or 4 digits
+	Cvd         string // skipped if empty
+}
+
+/*
+Validate checks card and returns Errors, or nil if it passes. now is the
+current time in the merchant's timezone: a card is good until the end of its
+expiry month there.
+*/
+func Validate(card Card, now time.Time) error {
+	var errs Errors
+	number := Normalize(card.Number)
+	rule, known := ruleFor(number)
+	switch {
+	case number == "":
+		errs = append(errs, Error{"number", "is required"})
+	case !Luhn(number):
+		errs = append(errs, Error{"number", "is not a valid card number"})
+	case known && !contains(rule.lengths, len(number)):
+		errs = append(errs, Error{"number", fmt.Sprintf("has %d digits, which is wrong for %v", len(number), rule.brand.Name())})
+	case !known && (len(number) < 12 || len(number) > 19):
+		errs = append(errs, Error{"number", fmt.Sprintf("has %d digits, not 12 to 19", len(number))})
+	}
+	if card.Cvd != "" {
+		if err := validateCvd(rule, known, card.Cvd); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	errs = append(errs, validateExpiry(card.ExpiryMonth, card.ExpiryYear, now)...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateCvd(rule brandRule, known bool, cvd string) *Error {
+	if _, err := strconv.Atoi(cvd); err != nil || strings.HasPrefix(cvd, "-") || strings.HasPrefix(cvd, "+") {
+		return &Error{"cvd", "must be digits"}
+	}
+	if known && len(cvd) != rule.cvdLength {
+		return &Error{"cvd", fmt.Sprintf("must be %d digits for %v", rule.cvdLength, rule.brand.Name())}
+	}
+	if !known && (len(cvd) < 3 || len(cvd) > 4) {
+		return &Error{"cvd", "must be 3 or 4 digits"}
+	}
+	return nil
+}
+
+func validateExpiry(month, year string, now time.Time) Errors {
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return Errors{{"expiry_month", "must be 1 to 12"}}
+	}
+	y, err := strconv.Atoi(year)
+	switch {
+	case err != nil || y < 0:
+		return Errors{{"expiry_year", "must be a year"}}
+	case len(year) <= 2:
+		y += now.Year() / 100 * 100
+	case len(year) != 4:
+		return Errors{{"expiry_year", "must be 2 or 4 digits"}}
+	}
+	// the card is good through the last moment of its expiry month
+	end := time.Date(y, time.Month(m)+1, 1, 0, 0, 0, 0, now.Location())
+	if !now.Before(end) {
+		return Errors{{"expiry_year", fmt.Sprintf("the card expired at the end of %02d/%d", m, y)}}
+	}
+	return nil
+}
+
+// Name is the brand's name, eg "American Express".
+func (b Brand) Name() string {
+	switch b {
+	case Visa:
+		return "Visa"
+	case Mastercard:
+		return "Mastercard"
+	case Amex:
+		return "American Express"
+	case Discover:
+		return "Discover"
+	case Diners:
+		return "Diners Club"
+	case JCB:
+		return "JCB"
+	}
+	return "an unknown brand"
+}
+
+func contains(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// +build unit integration
+
+package cardValidation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+func TestUnit_CardValidation_Luhn(t *testing.T) {
+	assert.True(t, Luhn("4030000010001234"))
+	assert.True(t, Luhn("5100000010001004"))
+	assert.True(t, Luhn("371100001000131"))
+	assert.False(t, Luhn("4030000010001235"))
+	assert.False(t, Luhn("4030 0000"))
+	assert.False(t, Luhn(""))
+}
+
+func TestUnit_CardValidation_DetectBrand(t *testing.T) {
+	assert.Equal(t, Visa, DetectBrand("4030 0000 1000 1234"))
+	assert.Equal(t, Mastercard, DetectBrand("5100000010001004"))
+	assert.Equal(t, Mastercard, DetectBrand("2223000048400011"))
+	assert.Equal(t, Amex, DetectBrand("371100001000131"))
+	assert.Equal(t, Discover, DetectBrand("6011000990139424"))
+	assert.Equal(t, Discover, DetectBrand("6221260000000000"))
+	assert.Equal(t, Diners, DetectBrand("30569309025904"))
+	assert.Equal(t, JCB, DetectBrand("3530111333300000"))
+	assert.Equal(t, Unknown, DetectBrand("9999999999999995"))
+	assert.Equal(t, Unknown, DetectBrand(""))
+}
+
+func TestUnit_CardValidation_Valid(t *testing.T) {
+	assert.Nil(t, Validate(Card{"4030-0000-1000-1234", "03", "26", "123"}, now))
+	assert.Nil(t, Validate(Card{"371100001000131", "12", "2030", "1234"}, now))
+	assert.Nil(t, Validate(Card{"5100000010001004", "4", "27", ""}, now))
+}
+
+func TestUnit_CardValidation_Invalid(t *testing.T) {
+	err := Validate(Card{"4030000010001235", "13", "26", "12a"}, now)
+	assert.Equal(t, Errors{
+		{"number", "is not a valid card number"},
+		{"cvd", "must be digits"},
+		{"expiry_month", "must be 1 to 12"}}, err)
+
+	err = Validate(Card{"371100001000131", "02", "26", "123"}, now)
+	assert.Equal(t, Errors{
+		{"cvd", "must be 4 digits for American Express"},
+		{"expiry_year", "the card expired at the end of 02/2026"}}, err)
+
+	// a valid Luhn number of the wrong length for Visa
+	err = Validate(Card{"40300000100012", "03", "26", ""}, now)
+	assert.Equal(t, Errors{{"number", "has 14 digits, which is wrong for Visa"}}, err)
+	assert.Equal(t, "invalid card: number has 14 digits, which is wrong for Visa", err.Error())
+}
+
+func TestUnit_CardValidation_ExpiryInTimezone(t *testing.T) {
+	card := Card{"4030000010001234", "03", "26", ""}
+	// 03:00 on April 1st in UTC is still March 31st in Toronto
+	toronto := time.FixedZone("UTC-05:00", -5*60*60)
+	april := time.Date(2026, time.April, 1, 3, 0, 0, 0, time.UTC)
+	assert.NotNil(t, Validate(card, april))
+	assert.Nil(t, Validate(card, april.In(toronto)))
+}
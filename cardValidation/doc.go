@@ -0,0 +1,10 @@
+/*
+Client-side checks of card details, run before they are sent to the gateway
+so that a typo does not cost a round trip and a declined transaction.
+
+Validate runs a Luhn check on the number, detects the card brand from its BIN,
+checks the lengths of the number and CVD for that brand, and makes sure the
+expiry date is valid and has not passed. The checks only catch mistakes in
+typing; a card that passes can still be declined.
+*/
+package cardValidation
@@ -0,0 +1,37 @@
+package beanstream
+
+import (
+	"fmt"
+	"github.com/Beanstream/beanstream-go/cardValidation"
+	"time"
+)
+
+/*
+checkCard runs cardValidation on card before it is sent, in the merchant's
+timezone so that a card expiring this month is accepted until the month ends
+where the merchant is. Problems are returned as a 400 error with one
+ErrorDetail per field, named as the gateway would name them.
+*/
+func checkCard(config Config, card CreditCard) error {
+	loc, err := config.Location()
+	if err != nil {
+		return &BeanstreamApiException{400, 0, 0, fmt.Sprintf("cannot check card expiry: %v", err), "", nil, nil}
+	}
+	err = cardValidation.Validate(cardValidation.Card{
+		Number:      card.Number,
+		ExpiryMonth: card.ExpiryMonth,
+		ExpiryYear:  card.ExpiryYear,
+		Cvd:         card.Cvd}, time.Now().In(loc))
+	errs, ok := err.(cardValidation.Errors)
+	if !ok {
+		return err
+	}
+	details := make([]ErrorDetail, len(errs))
+	for i, e := range errs {
+		details[i] = ErrorDetail{"card." + e.Field, e.Message}
+	}
+	return &BeanstreamApiException{
+		Status:  400,
+		Message: errs.Error(),
+		Details: details}
+}
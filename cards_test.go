@@ -0,0 +1,63 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"context"
+	"errors"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestUnit_Cards_CheckedBeforeSending(t *testing.T) {
+	fake := &fakeGateway{}
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), CheckCards: true}
+	card := CreditCard{Name: "John Doe", Number: "4030000010001235", ExpiryMonth: "11", ExpiryYear: "99", Cvd: "123"}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("10.00"), Card: card})
+	apiErr, ok := err.(*BeanstreamApiException)
+	assert.True(t, ok)
+	assert.Equal(t, 400, apiErr.Status)
+	assert.Equal(t, []ErrorDetail{{"card.number", "is not a valid card number"}}, apiErr.Details)
+	_, err = gateway.Profiles().CreateProfile(Profile{Card: card})
+	assert.NotNil(t, err)
+	_, err = gateway.Profiles().AddCard("PROFILE1", card)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(fake.received()))
+
+	card.Number = "4030000010001234"
+	_, err = gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("10.00"), Card: card})
+	assert.Nil(t, err)
+	_, err = gateway.Profiles().CreateProfile(Profile{Token: Token{Token: "abc", Name: "John Doe"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(fake.received()))
+}
+
+func TestUnit_Cards_NotCheckedByDefault(t *testing.T) {
+	fake := &fakeGateway{}
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+	card := CreditCard{Number: "1234"}
+	_, err := gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("10.00"), Card: card})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(fake.received()))
+}
+
+func TestUnit_Cards_ProfileContextForwarded(t *testing.T) {
+	fake := &fakeGateway{}
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+	profile := &Profile{Id: "PROFILE1"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := profile.GetCardsContext(ctx, gateway.Profiles())
+	assert.True(t, errors.Is(err, context.Canceled), "Error is not context.Canceled: %v", err)
+	_, err = profile.DeleteCardContext(ctx, gateway.Profiles(), 1)
+	assert.True(t, errors.Is(err, context.Canceled), "Error is not context.Canceled: %v", err)
+	assert.Equal(t, 0, len(fake.received()))
+
+	_, err = profile.DeleteCard(gateway.Profiles(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, fake.count(http.MethodDelete, "/profiles/PROFILE1/cards/1"))
+}
@@ -1,19 +1,47 @@
 package beanstream
 
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
 const bicUrlProtocol = "https://"
 const bicUrl = "beanstream.com"
+const defaultTokenizationUrl = "https://www.beanstream.com/scripts/tokenization/tokens"
 
 // Configuration for the Beanstream Gateway. To get some default values
 // call DefaultConfig()
+//
+// By default the API address is built from UrlPrefix, UrlApi and UrlApiVersion.
+// Set ApiBaseUrl to use a full address instead, such as a local mock server
+// or a regional endpoint. TokenizationUrl does the same for Legato.
 type Config struct {
-	MerchantId      string
-	PaymentsApiKey  string
-	ProfilesApiKey  string
-	ReportingApiKey string
-	UrlPrefix       string //"www"
-	UrlApi          string //"api"
-	UrlApiVersion   string //"v1"
-	TimezoneOffset  string //eg -8:00
+	MerchantId      string `json:"merchant_id"`
+	PaymentsApiKey  string `json:"payments_api_key"`
+	ProfilesApiKey  string `json:"profiles_api_key"`
+	ReportingApiKey string `json:"reporting_api_key"`
+	UrlPrefix       string `json:"url_prefix"`       //"www"
+	UrlApi          string `json:"url_api"`          //"api"
+	UrlApiVersion   string `json:"url_api_version"`  //"v1"
+	TimezoneOffset  string `json:"timezone_offset"`  //eg -8:00
+	ApiBaseUrl      string `json:"api_base_url"`     //eg http://localhost:8080/api/v1
+	TokenizationUrl string `json:"tokenization_url"` //eg http://localhost:8080/scripts/tokenization/tokens
+
+	// Currency is the currency the merchant account settles in, eg CAD. When
+	// set, payments in another currency, or with no currency, are rejected
+	// before they are sent, and every response and report record is labelled
+	// with it.
+	Currency Currency `json:"currency"`
+
+	// Credentials, if set, supplies the merchant ID and passcodes on every
+	// request instead of the fields above. See CredentialProvider.
+	Credentials CredentialProvider `json:"-"`
 }
 
 // Create a config object with the default details.
@@ -28,6 +56,310 @@ func DefaultConfig() Config {
 }
 
 func (v Config) BaseUrl() string {
+	if v.ApiBaseUrl != "" {
+		return strings.TrimSuffix(v.ApiBaseUrl, "/")
+	}
 	// https://www.beanstream.com/api/v1
 	return bicUrlProtocol + v.UrlPrefix + "." + bicUrl + "/" + v.UrlApi + "/" + v.UrlApiVersion
 }
+
+// TokenUrl is the Legato tokenization address.
+func (v Config) TokenUrl() string {
+	if v.TokenizationUrl != "" {
+		return v.TokenizationUrl
+	}
+	return defaultTokenizationUrl
+}
+
+/*
+Environment is a named set of gateway endpoints, and for Sandbox the merchant
+account to use with them. Beanstream has no separate sandbox host: test
+merchant accounts process on the live gateway, and it is their merchant ID and
+passcodes that keep test payments apart.
+*/
+type Environment struct {
+	Name            string
+	ApiBaseUrl      string
+	TokenizationUrl string
+
+	// The merchant ID and passcodes UseEnvironment fills in when the Config
+	// has no merchant ID of its own. Empty for Production.
+	MerchantId      string
+	PaymentsApiKey  string
+	ProfilesApiKey  string
+	ReportingApiKey string
+}
+
+// Production is the live Beanstream gateway, used with your own merchant account.
+var Production = Environment{
+	Name:            "production",
+	ApiBaseUrl:      "https://www.beanstream.com/api/v1",
+	TokenizationUrl: defaultTokenizationUrl}
+
+// Sandbox is the live gateway with Beanstream's public test merchant account,
+// whose payments are never settled. A Config with its own merchant ID, such as
+// a private test account, keeps it.
+var Sandbox = Environment{
+	Name:            "sandbox",
+	ApiBaseUrl:      "https://www.beanstream.com/api/v1",
+	TokenizationUrl: defaultTokenizationUrl,
+	MerchantId:      "300200578",
+	PaymentsApiKey:  "4BaD82D9197b4cc4b70a221911eE9f70",
+	ProfilesApiKey:  "D97D3BE1EE964A6193D17A571D9FBC80",
+	ReportingApiKey: "4e6Ff318bee64EA391609de89aD4CF5d"}
+
+// UseEnvironment points the config at the endpoints of env, and at its
+// merchant account if env has one and the config does not.
+func (v *Config) UseEnvironment(env Environment) {
+	v.ApiBaseUrl = env.ApiBaseUrl
+	v.TokenizationUrl = env.TokenizationUrl
+	if v.MerchantId == "" && env.MerchantId != "" {
+		v.MerchantId = env.MerchantId
+		v.PaymentsApiKey = env.PaymentsApiKey
+		v.ProfilesApiKey = env.ProfilesApiKey
+		v.ReportingApiKey = env.ReportingApiKey
+	}
+}
+
+// EnvironmentByName finds Production or Sandbox by name.
+func EnvironmentByName(name string) (Environment, bool) {
+	for _, env := range []Environment{Production, Sandbox} {
+		if strings.EqualFold(env.Name, name) {
+			return env, true
+		}
+	}
+	return Environment{}, false
+}
+
+// DefaultEnvPrefix is the prefix ConfigFromEnv uses when given an empty one.
+const DefaultEnvPrefix = "BEANSTREAM_"
+
+/*
+ConfigFromEnv builds a Config from environment variables, starting from
+DefaultConfig(). Only variables that are set override the defaults. With the
+default prefix they are:
+	BEANSTREAM_MERCHANT_ID
+	BEANSTREAM_PAYMENTS_API_KEY
+	BEANSTREAM_PROFILES_API_KEY
+	BEANSTREAM_REPORTING_API_KEY
+	BEANSTREAM_URL_PREFIX
+	BEANSTREAM_URL_API
+	BEANSTREAM_URL_API_VERSION
+	BEANSTREAM_TIMEZONE_OFFSET
+	BEANSTREAM_ENVIRONMENT       (production or sandbox)
+	BEANSTREAM_API_BASE_URL
+	BEANSTREAM_TOKENIZATION_URL
+	BEANSTREAM_CURRENCY
+An explicit API_BASE_URL or TOKENIZATION_URL wins over ENVIRONMENT, and so
+does an explicit MERCHANT_ID over the sandbox account.
+The Config is not validated; call Validate for that.
+*/
+func ConfigFromEnv(prefix string) (Config, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	cfg := DefaultConfig()
+	vars := []struct {
+		name  string
+		field *string
+	}{
+		{"MERCHANT_ID", &cfg.MerchantId},
+		{"PAYMENTS_API_KEY", &cfg.PaymentsApiKey},
+		{"PROFILES_API_KEY", &cfg.ProfilesApiKey},
+		{"REPORTING_API_KEY", &cfg.ReportingApiKey},
+		{"URL_PREFIX", &cfg.UrlPrefix},
+		{"URL_API", &cfg.UrlApi},
+		{"URL_API_VERSION", &cfg.UrlApiVersion},
+		{"TIMEZONE_OFFSET", &cfg.TimezoneOffset},
+		{"API_BASE_URL", &cfg.ApiBaseUrl},
+		{"TOKENIZATION_URL", &cfg.TokenizationUrl},
+		{"CURRENCY", (*string)(&cfg.Currency)}}
+	for _, v := range vars {
+		if val, ok := os.LookupEnv(prefix + v.name); ok {
+			*v.field = val
+		}
+	}
+	if name, ok := os.LookupEnv(prefix + "ENVIRONMENT"); ok {
+		env, found := EnvironmentByName(name)
+		if !found {
+			return cfg, fmt.Errorf("beanstream: unknown environment %q in %vENVIRONMENT", name, prefix)
+		}
+		// defaults are empty, so any URL set here was set explicitly
+		apiBaseUrl, tokenizationUrl := cfg.ApiBaseUrl, cfg.TokenizationUrl
+		cfg.UseEnvironment(env)
+		if apiBaseUrl != "" {
+			cfg.ApiBaseUrl = apiBaseUrl
+		}
+		if tokenizationUrl != "" {
+			cfg.TokenizationUrl = tokenizationUrl
+		}
+	}
+	return cfg, nil
+}
+
+/*
+ConfigFromFile reads a JSON config file on top of DefaultConfig(). Keys that
+are left out keep their default values:
+	{
+		"merchant_id": "300200578",
+		"payments_api_key": "4BaD82D9197b4cc4b70a221911eE9f70",
+		"reporting_api_key": "4e6Ff318bee64EA391609de89aD4CF5d",
+		"timezone_offset": "-8:00",
+		"environment": "sandbox"
+	}
+The file may also set url_prefix, url_api, url_api_version, api_base_url,
+tokenization_url and currency. With "sandbox", a file that leaves out merchant_id
+gets the public test account. The Config is not validated; call Validate for that.
+*/
+func ConfigFromFile(path string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	// decode the endpoints separately so they can override the environment
+	var file struct {
+		Environment     string  `json:"environment"`
+		ApiBaseUrl      *string `json:"api_base_url"`
+		TokenizationUrl *string `json:"tokenization_url"`
+	}
+	if err = json.Unmarshal(data, &file); err != nil {
+		return cfg, fmt.Errorf("beanstream: reading %v: %v", path, err)
+	}
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("beanstream: reading %v: %v", path, err)
+	}
+	if file.Environment != "" {
+		env, found := EnvironmentByName(file.Environment)
+		if !found {
+			return cfg, fmt.Errorf("beanstream: unknown environment %q in %v", file.Environment, path)
+		}
+		cfg.UseEnvironment(env)
+		if file.ApiBaseUrl != nil {
+			cfg.ApiBaseUrl = *file.ApiBaseUrl
+		}
+		if file.TokenizationUrl != nil {
+			cfg.TokenizationUrl = *file.TokenizationUrl
+		}
+	}
+	return cfg, nil
+}
+
+// ApiName names one of the three gateway APIs. Each has its own passcode.
+type ApiName string
+
+const (
+	PaymentsApi  ApiName = "payments"
+	ProfilesApi  ApiName = "profiles"
+	ReportingApi ApiName = "reporting"
+)
+
+// ConfigError lists everything Validate found wrong with a Config, as
+// field and message pairs.
+type ConfigError struct {
+	Details []ErrorDetail
+}
+
+func (e *ConfigError) Error() string {
+	msgs := make([]string, len(e.Details))
+	for i, d := range e.Details {
+		msgs[i] = d.Field + ": " + d.Message
+	}
+	return "beanstream: invalid config: " + strings.Join(msgs, "; ")
+}
+
+/*
+Validate checks the config before any request is made, so that a typo shows
+up at startup rather than as a 401 from the gateway. Pass the APIs you intend
+to use; their passcodes must be set:
+	if err := config.Validate(beanstream.PaymentsApi, beanstream.ReportingApi); err != nil {
+		log.Fatal(err)
+	}
+It reports a missing or non-numeric merchant ID, missing passcodes, a
+TimezoneOffset that is not of the form -8:00, a Currency that is not a 3 letter
+code, and malformed endpoint URLs.
+When Credentials is set the merchant ID and passcodes come from it, so only
+a merchant ID that is present is checked. The returned error is a
+*ConfigError.
+*/
+func (v Config) Validate(apis ...ApiName) error {
+	var details []ErrorDetail
+	add := func(field string, message string) {
+		details = append(details, ErrorDetail{field, message})
+	}
+
+	if v.MerchantId == "" {
+		if v.Credentials == nil {
+			add("MerchantId", "merchant ID is missing")
+		}
+	} else if _, err := strconv.ParseUint(v.MerchantId, 10, 64); err != nil {
+		add("MerchantId", "merchant ID must be numeric")
+	}
+	for _, api := range apis {
+		switch api {
+		case PaymentsApi:
+			if v.PaymentsApiKey == "" && v.Credentials == nil {
+				add("PaymentsApiKey", "passcode for the payments API is missing")
+			}
+		case ProfilesApi:
+			if v.ProfilesApiKey == "" && v.Credentials == nil {
+				add("ProfilesApiKey", "passcode for the profiles API is missing")
+			}
+		case ReportingApi:
+			if v.ReportingApiKey == "" && v.Credentials == nil {
+				add("ReportingApiKey", "passcode for the reporting API is missing")
+			}
+		default:
+			add("", fmt.Sprintf("unknown API %q", api))
+		}
+	}
+	if _, err := parseTimezoneOffset(v.TimezoneOffset); err != nil {
+		add("TimezoneOffset", err.Error())
+	}
+	if v.Currency != "" && !v.Currency.Valid() {
+		add("Currency", "currency must be a 3 letter ISO 4217 code such as CAD")
+	}
+	if err := checkUrl(v.BaseUrl()); err != nil {
+		add("ApiBaseUrl", err.Error())
+	}
+	if err := checkUrl(v.TokenUrl()); err != nil {
+		add("TokenizationUrl", err.Error())
+	}
+
+	if details != nil {
+		return &ConfigError{details}
+	}
+	return nil
+}
+
+var timezoneOffsetPattern = regexp.MustCompile(`^([+-]?)(\d{1,2}):(\d{2})$`)
+
+// parseTimezoneOffset turns an offset such as "-8:00" into seconds east of UTC.
+func parseTimezoneOffset(offset string) (int, error) {
+	m := timezoneOffsetPattern.FindStringSubmatch(offset)
+	if m == nil {
+		return 0, fmt.Errorf("timezone offset %q is not of the form -8:00", offset)
+	}
+	hours, _ := strconv.Atoi(m[2])
+	minutes, _ := strconv.Atoi(m[3])
+	if hours > 14 || minutes > 59 {
+		return 0, fmt.Errorf("timezone offset %q is out of range", offset)
+	}
+	seconds := hours*3600 + minutes*60
+	if m[1] == "-" {
+		seconds = -seconds
+	}
+	return seconds, nil
+}
+
+// checkUrl makes sure u is an absolute http or https URL.
+func checkUrl(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("url %q is malformed: %v", u, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https URL", u)
+	}
+	return nil
+}
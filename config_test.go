@@ -0,0 +1,105 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnit_Config_FromEnv(t *testing.T) {
+	t.Setenv("BEANSTREAM_MERCHANT_ID", "300200578")
+	t.Setenv("BEANSTREAM_PAYMENTS_API_KEY", "4BaD82D9197b4cc4b70a221911eE9f70")
+	t.Setenv("BEANSTREAM_TIMEZONE_OFFSET", "-8:00")
+	t.Setenv("BEANSTREAM_ENVIRONMENT", "production")
+
+	config, err := ConfigFromEnv("")
+	assert.Nil(t, err)
+	assert.Equal(t, "300200578", config.MerchantId)
+	assert.Equal(t, "4BaD82D9197b4cc4b70a221911eE9f70", config.PaymentsApiKey)
+	assert.Equal(t, "-8:00", config.TimezoneOffset)
+	assert.Equal(t, "www", config.UrlPrefix, "default was not kept")
+	assert.Equal(t, Production.ApiBaseUrl, config.ApiBaseUrl)
+	assert.Nil(t, config.Validate(PaymentsApi))
+}
+
+func TestUnit_Config_FromEnvUnknownEnvironment(t *testing.T) {
+	t.Setenv("MYAPP_ENVIRONMENT", "staging")
+	_, err := ConfigFromEnv("MYAPP_")
+	assert.NotNil(t, err)
+}
+
+func TestUnit_Config_FromEnvSandbox(t *testing.T) {
+	t.Setenv("MYAPP_ENVIRONMENT", "sandbox")
+	config, err := ConfigFromEnv("MYAPP_")
+	assert.Nil(t, err)
+	assert.Equal(t, Sandbox.MerchantId, config.MerchantId)
+	assert.Equal(t, Sandbox.ProfilesApiKey, config.ProfilesApiKey)
+	assert.Nil(t, config.Validate(PaymentsApi, ProfilesApi, ReportingApi))
+
+	// a merchant of its own keeps its passcodes, even unset ones
+	t.Setenv("MYAPP_MERCHANT_ID", "300200579")
+	t.Setenv("MYAPP_PAYMENTS_API_KEY", "A1b2C3d4E5f6A1b2C3d4E5f6A1b2C3d4")
+	config, err = ConfigFromEnv("MYAPP_")
+	assert.Nil(t, err)
+	assert.Equal(t, "300200579", config.MerchantId)
+	assert.Equal(t, "A1b2C3d4E5f6A1b2C3d4E5f6A1b2C3d4", config.PaymentsApiKey)
+	assert.Equal(t, "", config.ProfilesApiKey)
+	assert.Equal(t, Sandbox.ApiBaseUrl, config.ApiBaseUrl)
+}
+
+func TestUnit_Config_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "beanstream.json")
+	err := ioutil.WriteFile(path, []byte(`{
+		"merchant_id": "300200578",
+		"reporting_api_key": "4e6Ff318bee64EA391609de89aD4CF5d",
+		"environment": "production",
+		"api_base_url": "http://localhost:8080/api/v1"
+	}`), 0600)
+	assert.Nil(t, err)
+
+	config, err := ConfigFromFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "300200578", config.MerchantId)
+	assert.Equal(t, "4e6Ff318bee64EA391609de89aD4CF5d", config.ReportingApiKey)
+	assert.Equal(t, "0:00", config.TimezoneOffset, "default was not kept")
+	assert.Equal(t, "http://localhost:8080/api/v1", config.BaseUrl())
+	assert.Equal(t, Production.TokenizationUrl, config.TokenUrl())
+	assert.Nil(t, config.Validate(ReportingApi))
+}
+
+func TestUnit_Config_FromFileMissing(t *testing.T) {
+	_, err := ConfigFromFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnit_Config_Validate(t *testing.T) {
+	config := DefaultConfig()
+	config.ReportingApiKey = ""
+	config.TimezoneOffset = "-8"
+	config.ApiBaseUrl = "localhost:8080"
+
+	err := config.Validate(PaymentsApi, ReportingApi)
+	configErr, ok := err.(*ConfigError)
+	assert.True(t, ok, "Error is not a ConfigError")
+	fields := []string{}
+	for _, d := range configErr.Details {
+		fields = append(fields, d.Field)
+	}
+	assert.Equal(t, []string{"MerchantId", "PaymentsApiKey", "ReportingApiKey", "TimezoneOffset", "ApiBaseUrl"}, fields)
+}
+
+func TestUnit_Config_ParseTimezoneOffset(t *testing.T) {
+	for offset, seconds := range map[string]int{"0:00": 0, "-8:00": -8 * 3600, "+5:30": 5*3600 + 1800, "10:00": 10 * 3600} {
+		s, err := parseTimezoneOffset(offset)
+		assert.Nil(t, err, offset)
+		assert.Equal(t, seconds, s, offset)
+	}
+	for _, offset := range []string{"", "Z", "-8", "-8:0", "25:00", "-8:75"} {
+		_, err := parseTimezoneOffset(offset)
+		assert.NotNil(t, err, offset)
+	}
+}
@@ -0,0 +1,98 @@
+package beanstream
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+/*
+CredentialProvider supplies the merchant ID and passcode for a request. The
+SDK asks for them on every request, right before it calls GenerateAuthCode,
+so a rotated passcode takes effect at once in every PaymentsAPI, ProfilesAPI
+and ReportsAPI value, including long-lived ones.
+
+Set it on Config.Credentials. When it is nil the MerchantId and the
+PaymentsApiKey, ProfilesApiKey or ReportingApiKey of the Config are used.
+A provider may be called from several goroutines at once.
+*/
+type CredentialProvider interface {
+	Credentials(ctx context.Context, api ApiName) (merchantId string, passcode string, err error)
+}
+
+/*
+CredentialRefresher is a CredentialProvider that can fetch new passcodes, for
+example from a secrets manager. When the gateway rejects a request as
+unauthorized (401), the SDK calls Refresh once and repeats the request with
+the new credentials. A 401 means the gateway did not process the request, so
+this is safe for payments too.
+*/
+type CredentialRefresher interface {
+	CredentialProvider
+	Refresh(ctx context.Context, api ApiName) error
+}
+
+/*
+StoredCredentials is a CredentialProvider that keeps the passcodes in memory.
+Call SetPasscode to rotate one; requests made after that use the new value.
+	creds := beanstream.NewStoredCredentials(config)
+	config.Credentials = creds
+	gateway := beanstream.Gateway{Config: config}
+	...
+	creds.SetPasscode(beanstream.PaymentsApi, newPasscode)
+*/
+type StoredCredentials struct {
+	mu         sync.RWMutex
+	merchantId string
+	passcodes  map[ApiName]string
+}
+
+// NewStoredCredentials starts with the merchant ID and passcodes of config.
+func NewStoredCredentials(config Config) *StoredCredentials {
+	return &StoredCredentials{
+		merchantId: config.MerchantId,
+		passcodes: map[ApiName]string{
+			PaymentsApi:  config.PaymentsApiKey,
+			ProfilesApi:  config.ProfilesApiKey,
+			ReportingApi: config.ReportingApiKey}}
+}
+
+// Credentials returns the merchant ID and the current passcode for api.
+func (s *StoredCredentials) Credentials(ctx context.Context, api ApiName) (string, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.merchantId, s.passcodes[api], nil
+}
+
+// SetPasscode replaces the passcode used for api.
+func (s *StoredCredentials) SetPasscode(api ApiName, passcode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.passcodes[api] = passcode
+}
+
+// credentials returns the merchant ID and passcode to send to api. Calls
+// without an API, such as Legato tokenization, are not authenticated.
+func (v Config) credentials(ctx context.Context, api ApiName) (string, string, error) {
+	if api == "" {
+		return "", "", nil
+	}
+	if v.Credentials != nil {
+		return v.Credentials.Credentials(ctx, api)
+	}
+	switch api {
+	case PaymentsApi:
+		return v.MerchantId, v.PaymentsApiKey, nil
+	case ProfilesApi:
+		return v.MerchantId, v.ProfilesApiKey, nil
+	case ReportingApi:
+		return v.MerchantId, v.ReportingApiKey, nil
+	}
+	return v.MerchantId, "", nil
+}
+
+// isUnauthorized reports whether the gateway rejected the credentials.
+func isUnauthorized(err error) bool {
+	e, ok := err.(*BeanstreamApiException)
+	return ok && e.Status == http.StatusUnauthorized
+}
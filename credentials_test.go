@@ -0,0 +1,99 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const authFailed = `{"code":21,"category":1,"message":"Authentication failed"}`
+
+// rotatingCredentials switches to the next passcode when refreshed.
+type rotatingCredentials struct {
+	*StoredCredentials
+	next      string
+	err       error
+	refreshes int
+}
+
+func (r *rotatingCredentials) Refresh(ctx context.Context, api ApiName) error {
+	r.refreshes++
+	if r.err != nil {
+		return r.err
+	}
+	r.SetPasscode(api, r.next)
+	return nil
+}
+
+func TestUnit_Credentials_Rotation(t *testing.T) {
+	config := DefaultConfig()
+	creds := NewStoredCredentials(config)
+	config.Credentials = creds
+	fake := (&fakeGateway{}).on("", "", 401, authFailed).on("", "", 200, `{"order_number":"TEST1"}`)
+	gateway := Gateway{Config: config, HTTPClient: fake.client()}
+	payments := gateway.Payments()
+
+	_, err := payments.MakePayment(PaymentRequest{})
+	assert.Equal(t, 401, err.(*BeanstreamApiException).Status)
+
+	creds.SetPasscode(PaymentsApi, "new-passcode")
+	res, err := payments.MakePayment(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "TEST1", res.OrderNumber)
+	assert.Equal(t, "Passcode "+GenerateAuthCode(config.MerchantId, "new-passcode"), fake.received()[1].Header.Get("Authorization"))
+}
+
+func TestUnit_Credentials_RefreshOn401(t *testing.T) {
+	config := DefaultConfig()
+	creds := &rotatingCredentials{StoredCredentials: NewStoredCredentials(config), next: "new-passcode"}
+	config.Credentials = creds
+	fake := (&fakeGateway{}).
+		on("", "", 401, authFailed).
+		on("", "", 200, `{"order_number":"TEST1"}`).
+		on("", "", 401, authFailed)
+	gateway := Gateway{Config: config, HTTPClient: fake.client()}
+
+	res, err := gateway.Payments().MakePayment(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "TEST1", res.OrderNumber)
+	assert.Equal(t, 1, creds.refreshes)
+	assert.Equal(t, 2, len(fake.received()))
+	assert.Equal(t, "Passcode "+GenerateAuthCode(config.MerchantId, "new-passcode"), fake.received()[1].Header.Get("Authorization"))
+
+	// a second 401 is returned rather than refreshing again
+	_, err = gateway.Payments().MakePayment(PaymentRequest{})
+	assert.Equal(t, 401, err.(*BeanstreamApiException).Status)
+	assert.Equal(t, 2, creds.refreshes)
+	assert.Equal(t, 4, len(fake.received()))
+}
+
+func TestUnit_Credentials_RefreshFails(t *testing.T) {
+	config := DefaultConfig()
+	creds := &rotatingCredentials{StoredCredentials: NewStoredCredentials(config), err: errors.New("vault unavailable")}
+	config.Credentials = creds
+	fake := (&fakeGateway{}).on("", "", 401, authFailed)
+	gateway := Gateway{Config: config, HTTPClient: fake.client()}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{})
+	reqErr, ok := err.(*RequestException)
+	assert.True(t, ok)
+	assert.Equal(t, NotSent, reqErr.Outcome)
+	assert.Equal(t, 1, len(fake.received()))
+}
+
+func TestUnit_Credentials_StaticFallback(t *testing.T) {
+	config := DefaultConfig()
+	id, passcode, _ := config.credentials(context.Background(), ProfilesApi)
+	assert.Equal(t, config.MerchantId, id)
+	assert.Equal(t, config.ProfilesApiKey, passcode)
+	id, passcode, _ = config.credentials(context.Background(), "")
+	assert.Equal(t, "", id+passcode)
+}
+
+func TestUnit_Credentials_Validate(t *testing.T) {
+	config := Config{TimezoneOffset: "-8:00", Credentials: NewStoredCredentials(Config{})}
+	assert.Nil(t, config.Validate(PaymentsApi, ProfilesApi))
+}
@@ -0,0 +1,79 @@
+package beanstream
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Currency is an ISO 4217 currency code, such as CAD or USD.
+type Currency string
+
+const (
+	CAD Currency = "CAD"
+	USD Currency = "USD"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Valid reports whether c looks like an ISO 4217 code: three capital letters.
+func (c Currency) Valid() bool {
+	return currencyPattern.MatchString(string(c))
+}
+
+/*
+checkCurrency rejects a request in a currency other than the one the merchant
+account settles in. A merchant account only ever processes its own currency,
+so a mismatch means the request was sent to the wrong merchant. A request
+that does not say its currency is refused too, since it cannot be told apart
+from one meant for another merchant. A Config without a Currency skips the
+check.
+*/
+func checkCurrency(config Config, currency Currency) error {
+	if config.Currency == "" || currency == config.Currency {
+		return nil
+	}
+	if currency == "" {
+		return &BeanstreamApiException{
+			Status:  400,
+			Message: fmt.Sprintf("merchant %v settles in %v, but the request has no currency", config.MerchantId, config.Currency),
+			Details: []ErrorDetail{{"currency", "is required when the merchant's settlement currency is set"}}}
+	}
+	return &BeanstreamApiException{
+		Status:  400,
+		Message: fmt.Sprintf("merchant %v settles in %v, not %v", config.MerchantId, config.Currency, currency),
+		Details: []ErrorDetail{{"currency", "does not match the merchant's settlement currency"}}}
+}
+
+// Totals sums the amounts of report records in one currency.
+type Totals struct {
+	Count       int
+	Amount      Money
+	Returns     Money
+	Completions Money
+}
+
+/*
+CurrencyTotals sums report records per currency, so amounts in different
+currencies are never added together. Records from a Config without a Currency
+are summed under the empty Currency.
+*/
+type CurrencyTotals map[Currency]Totals
+
+// Add counts rec in the totals of its currency.
+func (t CurrencyTotals) Add(rec TransactionRecord) {
+	sum := t[rec.Currency]
+	sum.Count++
+	sum.Amount += rec.Amount
+	sum.Returns += rec.Returns
+	sum.Completions += rec.Completions
+	t[rec.Currency] = sum
+}
+
+// TotalsByCurrency sums records per currency.
+func TotalsByCurrency(records []TransactionRecord) CurrencyTotals {
+	totals := CurrencyTotals{}
+	for _, rec := range records {
+		totals.Add(rec)
+	}
+	return totals
+}
@@ -0,0 +1,109 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUnit_Currency_Valid(t *testing.T) {
+	assert.True(t, CAD.Valid())
+	assert.True(t, Currency("EUR").Valid())
+	assert.False(t, Currency("cad").Valid())
+	assert.False(t, Currency("CADD").Valid())
+}
+
+func TestUnit_Currency_MismatchRejected(t *testing.T) {
+	fake := &fakeGateway{}
+	config := DefaultConfig()
+	config.Currency = CAD
+	gateway := Gateway{Config: config, HTTPClient: fake.client()}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{Amount: MustParseMoney("10.00"), Currency: USD})
+	apiErr, ok := err.(*BeanstreamApiException)
+	assert.True(t, ok)
+	assert.Equal(t, 400, apiErr.Status)
+	assert.Equal(t, "currency", apiErr.Details[0].Field)
+	_, err = gateway.Payments().CompletePayment("10000001", PaymentRequest{Currency: USD})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(fake.received()))
+
+	res, err := gateway.Payments().MakePayment(PaymentRequest{Amount: MustParseMoney("10.00"), Currency: CAD})
+	assert.Nil(t, err)
+	assert.Equal(t, CAD, res.Currency)
+	assert.Equal(t, 1, len(fake.received()))
+}
+
+func TestUnit_Currency_MissingRejected(t *testing.T) {
+	fake := (&fakeGateway{}).on(http.MethodPost, "/completions", 200, `{"id":"10000002","approved":"1","type":"PAC"}`)
+	config := DefaultConfig()
+	config.Currency = CAD
+	gateway := Gateway{Config: config, HTTPClient: fake.client()}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{Amount: MustParseMoney("10.00")})
+	apiErr, ok := err.(*BeanstreamApiException)
+	assert.True(t, ok)
+	assert.Equal(t, 400, apiErr.Status)
+	assert.Equal(t, "currency", apiErr.Details[0].Field)
+	_, err = gateway.Payments().CompletePayment("10000001", PaymentRequest{Amount: MustParseMoney("10.00")})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(fake.received()))
+
+	// a pre-auth completes and releases in the merchant's own currency
+	p := &PreAuth{api: gateway.Payments(), Id: "10000001", Authorized: MustParseMoney("10.00")}
+	_, err = p.Release()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, fake.count(http.MethodPost, "/completions"))
+
+	// without a Config.Currency nothing is checked
+	gateway.Config.Currency = ""
+	_, err = gateway.Payments().MakePayment(PaymentRequest{Amount: MustParseMoney("10.00")})
+	assert.Nil(t, err)
+}
+
+func TestUnit_Currency_RecordsLabelled(t *testing.T) {
+	fake := (&fakeGateway{}).on(http.MethodPost, "", 200, `{"records":[{"trn_id":1,"trn_amount":"5.00"}]}`)
+	config := DefaultConfig()
+	config.MerchantId = "300"
+	config.Currency = USD
+	gateway := Gateway{Config: config, HTTPClient: fake.client()}
+	records, err := gateway.Reports().Query(time.Now().Add(-time.Hour), time.Now(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, USD, records[0].Currency)
+}
+
+func TestUnit_Currency_TotalsByCurrency(t *testing.T) {
+	totals := TotalsByCurrency([]TransactionRecord{
+		{Amount: MustParseMoney("10.00"), Currency: CAD},
+		{Amount: MustParseMoney("2.50"), Returns: MustParseMoney("1.00"), Currency: CAD},
+		{Amount: MustParseMoney("7.00"), Currency: USD}})
+	assert.Equal(t, 2, len(totals))
+	assert.Equal(t, Totals{2, MustParseMoney("12.50"), MustParseMoney("1.00"), 0}, totals[CAD])
+	assert.Equal(t, Totals{1, MustParseMoney("7.00"), 0, 0}, totals[USD])
+}
+
+func TestUnit_Registry_RouteByCurrency(t *testing.T) {
+	registry := NewRegistry()
+	registry.Add("ca", Config{MerchantId: "100", Currency: CAD})
+	registry.Add("us", Config{MerchantId: "200", Currency: USD})
+	registry.AddRoute(registry.RouteByCurrency())
+
+	key, err := registry.Route(PaymentRequest{Currency: USD})
+	assert.Nil(t, err)
+	assert.Equal(t, "us", key)
+	key, err = registry.Route(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "ca", key)
+}
+
+func TestUnit_Config_ValidateCurrency(t *testing.T) {
+	config := DefaultConfig()
+	config.MerchantId = "300200578"
+	config.Currency = "dollars"
+	err := config.Validate()
+	assert.NotNil(t, err)
+	assert.Equal(t, "Currency", err.(*ConfigError).Details[0].Field)
+}
@@ -0,0 +1,122 @@
+package beanstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// gatewayTimeLayouts are the forms the gateway writes local timestamps in.
+var gatewayTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"1/2/2006 3:04:05 PM",
+}
+
+/*
+Location returns the fixed time zone of TimezoneOffset, the zone your merchant
+account is set to in the Beanstream back office. The gateway writes timestamps
+in that zone without saying so.
+*/
+func (v Config) Location() (*time.Location, error) {
+	seconds, err := parseTimezoneOffset(v.TimezoneOffset)
+	if err != nil {
+		return nil, err
+	}
+	return time.FixedZone("UTC"+v.TimezoneOffset, seconds), nil
+}
+
+/*
+ParseDate reads a timestamp from the gateway, such as "2015-03-13T08:59:24",
+in the time zone of config. A timestamp that carries its own offset keeps it.
+An empty value gives the zero time.
+*/
+func ParseDate(val string, config Config) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+		return t, nil
+	}
+	loc, err := config.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, layout := range gatewayTimeLayouts {
+		if t, err := time.ParseInLocation(layout, val, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse timestamp %q", val)
+}
+
+// The response types keep the gateway's timestamps in unexported fields until
+// afterDecode can parse them in the Config's time zone. A timestamp that cannot
+// be parsed is left zero, with the reason in the response's DateErr.
+
+func (t *PaymentResponse) UnmarshalJSON(data []byte) error {
+	type plain PaymentResponse
+	aux := struct {
+		*plain
+		Created string `json:"created"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	t.created = aux.Created
+	return nil
+}
+
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	type plain Transaction
+	aux := struct {
+		*plain
+		Created string `json:"created"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	t.created = aux.Created
+	return nil
+}
+
+func (a *Adjustment) UnmarshalJSON(data []byte) error {
+	type plain Adjustment
+	aux := struct {
+		*plain
+		Created string `json:"created"`
+	}{plain: (*plain)(a)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	a.created = aux.Created
+	return nil
+}
+
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	type plain Profile
+	aux := struct {
+		*plain
+		Modified string `json:"modified_date"`
+	}{plain: (*plain)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	p.modified = aux.Modified
+	return nil
+}
+
+func (r *TransactionRecord) UnmarshalJSON(data []byte) error {
+	type plain TransactionRecord
+	aux := struct {
+		*plain
+		RawDateTime string `json:"trn_date_time"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.dateTime = aux.RawDateTime
+	return nil
+}
@@ -0,0 +1,104 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUnit_Dates_ParseDate(t *testing.T) {
+	config := Config{TimezoneOffset: "-8:00"}
+	want := time.Date(2015, 3, 13, 16, 59, 24, 0, time.UTC)
+
+	for _, val := range []string{"2015-03-13T08:59:24", "2015-03-13 08:59:24", "3/13/2015 8:59:24 AM", "2015-03-13T16:59:24Z"} {
+		got, err := ParseDate(val, config)
+		assert.Nil(t, err, val)
+		assert.True(t, want.Equal(got), val)
+	}
+	got, err := ParseDate("", config)
+	assert.Nil(t, err)
+	assert.True(t, got.IsZero())
+
+	_, err = ParseDate("yesterday", config)
+	assert.NotNil(t, err)
+	_, err = ParseDate("2015-03-13T08:59:24", Config{TimezoneOffset: "PST"})
+	assert.NotNil(t, err)
+
+	assert.True(t, want.Equal(AsDate("2015-03-13T08:59:24", config)))
+	assert.True(t, AsDate("yesterday", config).IsZero())
+}
+
+func TestUnit_Dates_Location(t *testing.T) {
+	loc, err := Config{TimezoneOffset: "+5:30"}.Location()
+	assert.Nil(t, err)
+	_, offset := time.Date(2020, 1, 1, 0, 0, 0, 0, loc).Zone()
+	assert.Equal(t, 5*3600+30*60, offset)
+}
+
+func TestUnit_Dates_Transaction(t *testing.T) {
+	var tx Transaction
+	err := json.Unmarshal([]byte(`{"id":1,"created":"2015-03-13T08:59:24",
+		"adjusted_by":[{"id":2,"created":"2015-03-14T09:00:00"},{"id":3,"created":"2015-03-15T10:30:00"}]}`), &tx)
+	assert.Nil(t, err)
+	tx.afterDecode(Config{TimezoneOffset: "0:00"})
+	assert.Nil(t, tx.DateErr)
+	assert.Equal(t, time.Date(2015, 3, 13, 8, 59, 24, 0, time.UTC), tx.CreatedTime.UTC())
+	assert.Equal(t, time.Date(2015, 3, 14, 9, 0, 0, 0, time.UTC), tx.Adjustments[0].CreatedTime.UTC())
+	assert.Equal(t, time.Date(2015, 3, 15, 10, 30, 0, 0, time.UTC), tx.Adjustments[1].CreatedTime.UTC())
+}
+
+func TestUnit_Dates_ProfileAndRecords(t *testing.T) {
+	config := Config{TimezoneOffset: "0:00"}
+	var p Profile
+	assert.Nil(t, json.Unmarshal([]byte(`{"modified_date":"2016-01-02T03:04:05","status":"A"}`), &p))
+	p.afterDecode(config)
+	assert.Nil(t, p.DateErr)
+	assert.Equal(t, 2016, p.ModifiedDate.Year())
+	assert.Equal(t, "A", p.Status)
+
+	var r RecordsResult
+	assert.Nil(t, json.Unmarshal([]byte(`{"records":[{"trn_id":7,"trn_date_time":"2016-01-02 03:04:05"}]}`), &r))
+	r.afterDecode(config)
+	assert.Nil(t, r.Records[0].DateErr)
+	assert.Equal(t, 7, r.Records[0].TransactionId)
+	assert.Equal(t, 3, r.Records[0].DateTime.Hour())
+}
+
+func TestUnit_Dates_ErrorKeepsResponse(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/payments", 200, `{"id":"10000001","approved":"1","created":"not a date","card":{"cvd_match":2}}`).
+		on(http.MethodGet, "/profiles/ABC", 200, `{"modified_date":"not a date"}`).
+		on(http.MethodPost, "/reports", 200, `{"records":[{"trn_id":1,"trn_date_time":"not a date"},{"trn_id":2}]}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	// an approved payment is never reported as an error, which would invite a retry
+	res, err := gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CARD})
+	assert.Nil(t, err)
+	assert.Equal(t, "10000001", res.ID)
+	assert.True(t, res.CreatedTime.IsZero())
+	assert.NotNil(t, res.DateErr)
+
+	profile, err := gateway.Profiles().GetProfile("ABC")
+	assert.Nil(t, err)
+	assert.Equal(t, "ABC", profile.Id)
+	assert.NotNil(t, profile.DateErr)
+
+	records, err := gateway.Reports().Query(time.Now().Add(-time.Hour), time.Now(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.NotNil(t, records[0].DateErr)
+	assert.Nil(t, records[1].DateErr)
+
+	// and still goes through verification
+	gateway.Verification = VerificationPolicy{VoidOnCvdMismatch: true}
+	_, err = gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CARD})
+	var verr *VerificationException
+	assert.True(t, errors.As(err, &verr), "Error is not a VerificationException: %v", err)
+	assert.Equal(t, 1, fake.count(http.MethodPost, "/void"))
+}
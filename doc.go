@@ -8,24 +8,37 @@ Each API has its own Passcode and processes against your Merchant ID.
 
 To start using an API you must create a Gateway and supply it the configuration
 it needs to run:
-	gateway := beanstream.Gateway{beanstream.Config{
-		"300200578", // merchant ID
-		"4BaD82D9197b4cc4b70a221911eE9f70", // Payments Passcode
-		"D97D3BE1EE964A6193D17A571D9FBC80", // Profiles Passcode
-		"4e6Ff318bee64EA391609de89aD4CF5d", // Reporting Passcode
-		"www", // url prefix
-		"api", // url suffix
-		"v1", // api version
-		"-8:00"}} // timezone offset
-
-The above values use a Beanstream Test account.
+	gateway := beanstream.Gateway{Config: beanstream.Config{
+		MerchantId:      "300200578",
+		PaymentsApiKey:  "4BaD82D9197b4cc4b70a221911eE9f70",
+		ProfilesApiKey:  "D97D3BE1EE964A6193D17A571D9FBC80",
+		ReportingApiKey: "4e6Ff318bee64EA391609de89aD4CF5d",
+		UrlPrefix:       "www",
+		UrlApi:          "api",
+		UrlApiVersion:   "v1",
+		TimezoneOffset:  "-8:00"}}
+
+The above values use a Beanstream Test account. Test accounts process on the
+live gateway; there is no separate sandbox host, so it is the test merchant ID
+and passcodes that keep test payments apart. The Sandbox Environment fills
+them in for you:
+	config := beanstream.DefaultConfig()
+	config.UseEnvironment(beanstream.Sandbox)
+
+To send requests somewhere else, such as a local mock server, set a full
+ApiBaseUrl and TokenizationUrl on the Config:
+	config.ApiBaseUrl = "http://localhost:8080/api/v1"
+
+The Gateway sends every request through one http.Client so connections are
+reused. Set Gateway.HTTPClient to control timeouts, proxies or TLS settings:
+	gateway.HTTPClient = &http.Client{Timeout: 30 * time.Second}
 
 To Create a new payment (credit card, cash, cheque...) use the Payments API and supply
 it with a PaymentRrequest:
 	request := beanstream.PaymentRequest{
 		PaymentMethod: paymentMethods.CARD,
 		OrderNumber:   beanstream.Util_randOrderId(6),
-		Amount:        12.99,
+		Amount:        beanstream.MustParseMoney("12.99"),
 		Card: beanstream.CreditCard{
 			Name:        "John Doe",
 			Number:      "5100000010001004",
@@ -35,6 +48,49 @@ it with a PaymentRrequest:
 			Complete:    true}}
 	res, err := gateway.Payments().MakePayment(request)
 
+Every API call also has a Context variant, such as MakePaymentContext, that
+takes a context.Context. Cancelling the context, or letting its deadline pass,
+aborts the in-flight request and the call returns the context's error:
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	res, err := gateway.Payments().MakePaymentContext(ctx, request)
+
+Calls return one of two error types. A *BeanstreamApiException means the gateway
+answered with an error. A *RequestException means no answer was received; its
+Outcome says whether the request certainly did not reach the gateway (NotSent)
+or may have been processed (OutcomeUnknown), in which case a payment could have
+been made and should be looked up before retrying.
+
+A payment that needs the customer to authenticate with their issuer, such as
+3-D Secure, returns a *RedirectException instead. Send the customer to it and
+finish the payment with ContinuePayment once they come back to TermUrl.
+Interac Online payments (paymentMethods.INTERAC) always redirect to the
+customer's bank and are finished with ContinueInteracPayment.
+
+Passcodes that are rotated while the program runs can be supplied through
+Config.Credentials, which is consulted on every request. NewStoredCredentials
+gives an in-memory CredentialProvider whose SetPasscode takes effect at once.
+
+To process for several merchant accounts, register their Configs in a
+Registry. It routes each payment to a merchant and queries them all at once.
+Set Config.Currency to each merchant's settlement currency: payments must then
+set PaymentRequest.Currency to match, and report records carry the currency so
+TotalsByCurrency can sum them without mixing CAD and USD.
+
+For audits, set Gateway.CaptureResponses to keep the gateway's raw response
+on results and errors, or use CaptureResponse for a single call.
+
+BeanstreamApiException.MessageInfo tells a decline from an error worth
+retrying and gives text that is safe to show the customer. Look up
+PaymentResponse.MessageID the same way with Info.
+
+Card results decode their AVS and CVD codes; see AvsResult and CvdResult. Set
+Gateway.Verification to void approved payments that fail your risk rules.
+Set Gateway.CheckCards to catch mistyped card numbers, CVDs and expiry dates
+before they are sent; see the cardValidation package.
+PaymentRequest.Validate checks a request against the rules of its payment
+method and returns the problems as ErrorDetails, as the gateway would.
+
 For more details visit the documentation for each particular API.
 */
 package beanstream
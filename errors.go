@@ -11,6 +11,7 @@ type BeanstreamApiException struct {
 	Message   string
 	Reference string
 	Details   []ErrorDetail
+	Response  *ResponseInfo // set when Gateway.CaptureResponses is on
 }
 
 type ErrorDetail struct {
@@ -50,3 +51,53 @@ func (e *BeanstreamApiException) ErrorType() string {
 		return "InternalServerException"
 	}
 }
+
+// RequestOutcome tells whether a request that failed without a gateway response
+// could still have been processed by Beanstream.
+type RequestOutcome int
+
+const (
+	// NotSent means the request never left the SDK or never reached the gateway.
+	// No payment was made and the call can safely be repeated.
+	NotSent RequestOutcome = iota
+	// OutcomeUnknown means the request may have reached the gateway before the
+	// failure. A payment could have been made; check with GetTransaction or the
+	// Reports API before trying again.
+	OutcomeUnknown
+)
+
+func (o RequestOutcome) String() string {
+	switch o {
+	case NotSent:
+		return "not sent"
+	default:
+		return "outcome unknown"
+	}
+}
+
+/*
+RequestException is returned when a call fails before a response is received
+from the gateway: the request could not be built, the connection failed, the
+response could not be read, or the context was cancelled.
+
+Check Outcome to know whether the gateway may have acted on the request. The
+underlying error is available through errors.Is and errors.As, so a cancelled
+call matches context.Canceled.
+*/
+type RequestException struct {
+	Outcome RequestOutcome
+	Message string
+	Err     error
+}
+
+func (e *RequestException) Error() string {
+	return fmt.Sprintf("request %v: %v: %v", e.Outcome, e.Message, e.Err)
+}
+
+func (e *RequestException) Unwrap() error {
+	return e.Err
+}
+
+func (e RequestException) String() string {
+	return e.Error()
+}
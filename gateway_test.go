@@ -3,19 +3,218 @@
 package beanstream
 
 import (
+	"encoding/base64"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 )
 
 func TestUnit_Gateway_Config_BaseUrl(t *testing.T) {
 	config := Config{
-		"",
-		"",
-		"",
-		"",
-		"www",
-		"api",
-		"v1",
-		"-8:00"}
+		UrlPrefix:      "www",
+		UrlApi:         "api",
+		UrlApiVersion:  "v1",
+		TimezoneOffset: "-8:00"}
 	assert.EqualValues(t, "https://www.beanstream.com/api/v1", config.BaseUrl())
 }
+
+func TestUnit_Gateway_HTTPClientInherited(t *testing.T) {
+	fake := (&fakeGateway{}).on("", "", 200, `{"order_number":"TEST1"}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	res, err := gateway.Payments().MakePayment(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "TEST1", res.OrderNumber)
+	_, err = gateway.Payments().GetTransaction("10000001")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(fake.received()))
+	assert.Equal(t, "https://www.beanstream.com/api/v1/payments/10000001", fake.received()[1].URL)
+}
+
+func TestUnit_Gateway_DefaultHTTPClient(t *testing.T) {
+	gateway := Gateway{Config: DefaultConfig()}
+	assert.True(t, gateway.Payments().transport.httpClient() == defaultHTTPClient)
+	assert.True(t, gateway.Reports().transport.httpClient() == defaultHTTPClient)
+}
+
+func TestUnit_Gateway_Config_ApiBaseUrl(t *testing.T) {
+	config := DefaultConfig()
+	config.ApiBaseUrl = "http://localhost:8080/api/v1/"
+	assert.EqualValues(t, "http://localhost:8080/api/v1", config.BaseUrl())
+	assert.EqualValues(t, "https://www.beanstream.com/scripts/tokenization/tokens", config.TokenUrl())
+
+	config.TokenizationUrl = "http://localhost:8080/tokens"
+	assert.EqualValues(t, "http://localhost:8080/tokens", config.TokenUrl())
+}
+
+func TestUnit_Gateway_Config_Environment(t *testing.T) {
+	config := DefaultConfig()
+	assert.EqualValues(t, Production.ApiBaseUrl, config.BaseUrl())
+
+	config.ApiBaseUrl = "http://localhost:8080/api/v1"
+	config.UseEnvironment(Production)
+	assert.EqualValues(t, Production.ApiBaseUrl, config.BaseUrl())
+	assert.EqualValues(t, Production.TokenizationUrl, config.TokenUrl())
+	assert.EqualValues(t, "", config.MerchantId)
+
+	config.UseEnvironment(Sandbox)
+	assert.EqualValues(t, Sandbox.ApiBaseUrl, config.BaseUrl())
+	assert.EqualValues(t, Sandbox.MerchantId, config.MerchantId)
+}
+
+func TestUnit_Gateway_LocalServer(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/tokens" {
+			w.Write([]byte(`{"token":"gt7-1234","code":1}`))
+			return
+		}
+		w.Write([]byte(`{"id":"10000001","approved":"1","message_id":"1","order_number":"TEST1"}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.ApiBaseUrl = server.URL + "/api/v1"
+	config.TokenizationUrl = server.URL + "/tokens"
+	gateway := Gateway{Config: config}
+
+	res, err := gateway.Payments().MakePayment(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.True(t, res.IsApproved())
+	token, err := gateway.LegatoTokenizeCard("5100000010001004", "11", "19", "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "gt7-1234", token)
+	assert.Equal(t, []string{"/api/v1/payments", "/tokens"}, paths)
+}
+
+// fakeResponse is a JSON response from the gateway.
+func fakeResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req}
+}
+
+// fakeRequest is a request a fakeGateway received.
+type fakeRequest struct {
+	Method string
+	URL    string
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+// Merchant is the merchant ID the request was authenticated as.
+func (r fakeRequest) Merchant() string {
+	auth, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(r.Header.Get("Authorization"), "Passcode "))
+	return strings.SplitN(string(auth), ":", 2)[0]
+}
+
+type fakeRoute struct {
+	method  string // "" matches any method
+	suffix  string // matched against the end of the path
+	answers []func(req fakeRequest) (status int, body string)
+	used    int
+}
+
+/*
+fakeGateway plays the gateway in unit tests. It records every request and
+answers it from the first route whose method and path suffix match, or with
+200 and `{}` if none does. A route given more than one answer uses each of
+them once, in order, and then keeps repeating the last. Header is added to
+every response. A fakeGateway is safe for concurrent use.
+*/
+type fakeGateway struct {
+	Header   http.Header
+	mu       sync.Mutex
+	routes   []*fakeRoute
+	requests []fakeRequest
+}
+
+// on adds an answer of status and body for requests matching method and suffix.
+func (g *fakeGateway) on(method, suffix string, status int, body string) *fakeGateway {
+	return g.handle(method, suffix, func(fakeRequest) (int, string) { return status, body })
+}
+
+// handle adds an answer worked out by respond for requests matching method
+// and suffix.
+func (g *fakeGateway) handle(method, suffix string, respond func(req fakeRequest) (int, string)) *fakeGateway {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, route := range g.routes {
+		if route.method == method && route.suffix == suffix {
+			route.answers = append(route.answers, respond)
+			return g
+		}
+	}
+	g.routes = append(g.routes, &fakeRoute{method: method, suffix: suffix, answers: []func(fakeRequest) (int, string){respond}})
+	return g
+}
+
+func (g *fakeGateway) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := fakeRequest{Method: req.Method, URL: req.URL.String(), Path: req.URL.Path, Header: req.Header}
+	if req.Body != nil {
+		b, _ := ioutil.ReadAll(req.Body)
+		r.Body = string(b)
+	}
+	g.mu.Lock()
+	g.requests = append(g.requests, r)
+	respond := func(fakeRequest) (int, string) { return 200, `{}` }
+	for _, route := range g.routes {
+		if (route.method == "" || route.method == r.Method) && strings.HasSuffix(r.Path, route.suffix) {
+			respond = route.answers[len(route.answers)-1]
+			if route.used < len(route.answers) {
+				respond = route.answers[route.used]
+			}
+			route.used++
+			break
+		}
+	}
+	g.mu.Unlock()
+
+	status, body := respond(r)
+	res := fakeResponse(req, status, body)
+	for name, values := range g.Header {
+		res.Header[name] = values
+	}
+	return res, nil
+}
+
+// received returns the requests so far.
+func (g *fakeGateway) received() []fakeRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]fakeRequest(nil), g.requests...)
+}
+
+// calls lists the requests so far as "METHOD path".
+func (g *fakeGateway) calls() []string {
+	var calls []string
+	for _, r := range g.received() {
+		calls = append(calls, r.Method+" "+r.Path)
+	}
+	return calls
+}
+
+// count is the number of requests matching method and suffix.
+func (g *fakeGateway) count(method, suffix string) int {
+	n := 0
+	for _, r := range g.received() {
+		if (method == "" || method == r.Method) && strings.HasSuffix(r.Path, suffix) {
+			n++
+		}
+	}
+	return n
+}
+
+// client is an http.Client that sends everything to g.
+func (g *fakeGateway) client() *http.Client {
+	return &http.Client{Transport: g}
+}
@@ -0,0 +1,66 @@
+package beanstream
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation names passed to an Interceptor. Each names the API method that
+// made the call; GetCard and AddTokenizedCard share the names of GetCards and AddCard.
+const (
+	OpMakePayment     = "payments.make"
+	OpCompletePayment = "payments.complete"
+	OpVoidPayment     = "payments.void"
+	OpReturnPayment   = "payments.return"
+	OpContinuePayment = "payments.continue"
+	OpGetTransaction  = "payments.get"
+	OpCreateProfile   = "profiles.create"
+	OpGetProfile      = "profiles.get"
+	OpUpdateProfile   = "profiles.update"
+	OpDeleteProfile   = "profiles.delete"
+	OpGetCards        = "profiles.cards.get"
+	OpAddCard         = "profiles.cards.add"
+	OpUpdateCard      = "profiles.cards.update"
+	OpDeleteCard      = "profiles.cards.delete"
+	OpQuery           = "reports.query"
+	OpTokenizeCard    = "legato.tokenize"
+)
+
+// Invoker sends req to the gateway and returns the decoded response, or the
+// error that stopped it: a *BeanstreamApiException or a *RequestException.
+type Invoker func(ctx context.Context, req *http.Request) (interface{}, error)
+
+/*
+Interceptor wraps every request a Gateway makes, which lets you add headers
+such as correlation IDs, record metrics or keep an audit trail without
+changing the SDK.
+
+op is one of the Op constants. req is ready to send, with its Authorization
+and Content-Type headers set, and can be changed before calling next. The
+interceptor must call next to send the request and should return what next
+returns, though it may inspect or replace it. The response is a pointer to
+the API's result type, such as *PaymentResponse.
+
+Interceptors are set on the Gateway and run in order: the first one is
+outermost. When a read is retried, the chain runs again for every attempt.
+	gateway.Interceptors = []beanstream.Interceptor{
+		func(ctx context.Context, op string, req *http.Request, next beanstream.Invoker) (interface{}, error) {
+			req.Header.Set("X-Correlation-Id", correlationId(ctx))
+			start := time.Now()
+			res, err := next(ctx, req)
+			metrics.Observe(op, time.Since(start), err)
+			return res, err
+		}}
+*/
+type Interceptor func(ctx context.Context, op string, req *http.Request, next Invoker) (interface{}, error)
+
+// chain wraps invoke in the transport's interceptors, first one outermost.
+func (t transport) chain(op string, invoke Invoker) Invoker {
+	for i := len(t.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := t.interceptors[i], invoke
+		invoke = func(ctx context.Context, req *http.Request) (interface{}, error) {
+			return interceptor(ctx, op, req, next)
+		}
+	}
+	return invoke
+}
@@ -0,0 +1,59 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUnit_Interceptor_Chain(t *testing.T) {
+	var seen []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, op string, req *http.Request, next Invoker) (interface{}, error) {
+			seen = append(seen, name+" "+op)
+			req.Header.Set("X-Correlation-Id", "abc-123")
+			res, err := next(ctx, req)
+			seen = append(seen, name+" done")
+			return res, err
+		}
+	}
+	fake := (&fakeGateway{}).on(http.MethodPost, "/void", 200, `{"order_number":"TEST1"}`)
+	gateway := Gateway{
+		Config:       DefaultConfig(),
+		HTTPClient:   fake.client(),
+		Interceptors: []Interceptor{record("outer"), record("inner")}}
+
+	res, err := gateway.Payments().VoidPayment("10000001", MustParseMoney("12.99"))
+	assert.Nil(t, err)
+	assert.Equal(t, "TEST1", res.OrderNumber)
+	assert.Equal(t, []string{"outer payments.void", "inner payments.void", "inner done", "outer done"}, seen)
+	assert.Equal(t, "abc-123", fake.received()[0].Header.Get("X-Correlation-Id"))
+}
+
+func TestUnit_Interceptor_SeesResponseAndError(t *testing.T) {
+	var response interface{}
+	var callErr error
+	observe := func(ctx context.Context, op string, req *http.Request, next Invoker) (interface{}, error) {
+		res, err := next(ctx, req)
+		response, callErr = res, err
+		return res, err
+	}
+	fake := (&fakeGateway{}).on(http.MethodPost, "/reports", 404, `{"code":1,"category":1,"message":"Not found","reference":""}`)
+	gateway := Gateway{
+		Config:       DefaultConfig(),
+		HTTPClient:   fake.client(),
+		Interceptors: []Interceptor{observe}}
+
+	_, err := gateway.Profiles().GetProfile("ABC")
+	assert.Nil(t, err)
+	_, ok := response.(*Profile)
+	assert.True(t, ok, "Response is not a *Profile")
+
+	_, err = gateway.Reports().Query(time.Now(), time.Now(), 1, 2)
+	assert.NotNil(t, err)
+	assert.Equal(t, err, callErr)
+}
@@ -1,8 +1,9 @@
 package beanstream
 
-import "net/http"
-
-const url = "https://www.beanstream.com/scripts/tokenization/tokens"
+import (
+	"context"
+	"net/http"
+)
 
 type legatoCardRequest struct {
 	Number       string `json:"number"`
@@ -24,12 +25,30 @@ type legatoTokenResponse struct {
 // thus increasing the scope of your PCI compliance. The token should be
 // collected on the client-side app.
 func LegatoTokenizeCard(cardNumber string, expMo string, expYr string, cvd string) (string, error) {
+	return LegatoTokenizeCardContext(context.Background(), cardNumber, expMo, expYr, cvd)
+}
+
+// LegatoTokenizeCardContext is LegatoTokenizeCard with a context. Cancelling ctx aborts the request.
+func LegatoTokenizeCardContext(ctx context.Context, cardNumber string, expMo string, expYr string, cvd string) (string, error) {
+	return legatoTokenizeCard(ctx, transport{}, DefaultConfig(), cardNumber, expMo, expYr, cvd)
+}
+
+// LegatoTokenizeCard is the package LegatoTokenizeCard sent to the config's
+// TokenUrl through the gateway's HTTP client, logger and interceptors.
+func (v *Gateway) LegatoTokenizeCard(cardNumber string, expMo string, expYr string, cvd string) (string, error) {
+	return v.LegatoTokenizeCardContext(context.Background(), cardNumber, expMo, expYr, cvd)
+}
+
+// LegatoTokenizeCardContext is Gateway.LegatoTokenizeCard with a context.
+func (v *Gateway) LegatoTokenizeCardContext(ctx context.Context, cardNumber string, expMo string, expYr string, cvd string) (string, error) {
+	return legatoTokenizeCard(ctx, v.transport(), v.Config, cardNumber, expMo, expYr, cvd)
+}
+
+func legatoTokenizeCard(ctx context.Context, t transport, config Config, cardNumber string, expMo string, expYr string, cvd string) (string, error) {
 	req := legatoCardRequest{cardNumber, expMo, expYr, cvd}
-	responseType := legatoTokenResponse{}
-	res, err := ProcessBody(http.MethodPost, url, "", "", req, &responseType)
+	token, err := execute[legatoTokenResponse](ctx, t, call{OpTokenizeCard, http.MethodPost, config.TokenUrl(), config, "", false}, req)
 	if err != nil {
 		return "", err
 	}
-	token := res.(*legatoTokenResponse)
 	return token.Token, nil
 }
@@ -0,0 +1,94 @@
+package beanstream
+
+import (
+	"fmt"
+)
+
+/*
+Level2 is the purchase data that card networks ask of business-to-business
+payments, sent as PaymentRequest.Level2. Corporate and purchasing cards get
+lower interchange rates when it is present.
+*/
+type Level2 struct {
+	TaxAmount      Money  `json:"tax_amount,omitempty"`            // total tax included in Amount
+	TaxExempt      bool   `json:"tax_exempt,omitempty"`            // the buyer pays no tax
+	PurchaseOrder  string `json:"purchase_order_number,omitempty"` // the buyer's PO number
+	CustomerCode   string `json:"customer_code,omitempty"`         // the buyer's reference for their own records
+	ShippingAmount Money  `json:"shipping_amount,omitempty"`
+	DutyAmount     Money  `json:"duty_amount,omitempty"`
+}
+
+/*
+LineItem is one Level 3 line of a payment, sent in PaymentRequest.LineItems.
+Its Total is Quantity times UnitCost, less Discount, plus Tax:
+	beanstream.LineItem{
+		Sku:         "PAPER-A4",
+		Description: "A4 paper, box of 5 reams",
+		Quantity:    3,
+		UnitCost:    beanstream.MustParseMoney("24.00"),
+		Tax:         beanstream.MustParseMoney("9.36")}
+*/
+type LineItem struct {
+	Sku         string `json:"sku,omitempty"`
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	UnitCost    Money  `json:"unit_cost"`
+	Discount    Money  `json:"discount,omitempty"` // off the whole line, not each unit
+	Tax         Money  `json:"tax,omitempty"`
+	Unit        string `json:"unit_of_measure,omitempty"` // eg "EA" or "KG"
+	Commodity   string `json:"commodity_code,omitempty"`
+}
+
+// Total is what the line adds to the payment.
+func (i LineItem) Total() Money {
+	return Money(i.Quantity)*i.UnitCost - i.Discount + i.Tax
+}
+
+/*
+checkLineItems makes sure the Level 2 and Level 3 data add up before a
+request is sent, since the networks downgrade a payment whose line items do not
+match its amount. The items, plus any Level 2 shipping and duty, must total
+Amount, and their taxes must total Level2.TaxAmount when it is set.
+*/
+func checkLineItems(request PaymentRequest) error {
+	details := lineItemDetails(request)
+	if len(details) == 0 {
+		return nil
+	}
+	return &BeanstreamApiException{
+		Status:  400,
+		Message: "the line items do not match the payment",
+		Details: details}
+}
+
+// lineItemDetails lists the problems checkLineItems reports.
+func lineItemDetails(request PaymentRequest) []ErrorDetail {
+	if len(request.LineItems) == 0 {
+		return nil
+	}
+	var total, tax Money
+	var details []ErrorDetail
+	for i, item := range request.LineItems {
+		if item.Description == "" {
+			details = append(details, ErrorDetail{fmt.Sprintf("line_items[%d].description", i), "is required"})
+		}
+		if item.Quantity <= 0 {
+			details = append(details, ErrorDetail{fmt.Sprintf("line_items[%d].quantity", i), "must be positive"})
+		}
+		if item.UnitCost < 0 || item.Discount < 0 || item.Tax < 0 {
+			details = append(details, ErrorDetail{fmt.Sprintf("line_items[%d]", i), "amounts must not be negative"})
+		}
+		total += item.Total()
+		tax += item.Tax
+	}
+	if request.Level2 != nil {
+		total += request.Level2.ShippingAmount + request.Level2.DutyAmount
+		if request.Level2.TaxAmount != 0 && tax != request.Level2.TaxAmount {
+			details = append(details, ErrorDetail{"level_2.tax_amount", fmt.Sprintf("is %v but the line items' tax totals %v", request.Level2.TaxAmount, tax)})
+		}
+	}
+	if total != request.Amount {
+		details = append(details, ErrorDetail{"amount", fmt.Sprintf("is %v but the line items total %v", request.Amount, total)})
+	}
+	return details
+}
@@ -0,0 +1,73 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func level3Request() PaymentRequest {
+	return PaymentRequest{
+		Amount: MustParseMoney("89.36"),
+		Level2: &Level2{TaxAmount: MustParseMoney("9.36"), PurchaseOrder: "PO-1234", ShippingAmount: MustParseMoney("10.00")},
+		LineItems: []LineItem{
+			{Sku: "PAPER-A4", Description: "A4 paper", Quantity: 3, UnitCost: MustParseMoney("24.00"), Discount: MustParseMoney("2.00"), Tax: MustParseMoney("9.36")},
+			{Description: "Delivery note", Quantity: 1}}}
+}
+
+func TestUnit_LineItems_Total(t *testing.T) {
+	item := LineItem{Quantity: 3, UnitCost: MustParseMoney("24.00"), Discount: MustParseMoney("2.00"), Tax: MustParseMoney("9.36")}
+	assert.Equal(t, MustParseMoney("79.36"), item.Total())
+}
+
+func TestUnit_LineItems_Encoded(t *testing.T) {
+	b, err := json.Marshal(level3Request())
+	assert.Nil(t, err)
+	var sent map[string]interface{}
+	assert.Nil(t, json.Unmarshal(b, &sent))
+	assert.Equal(t, "PO-1234", sent["level_2"].(map[string]interface{})["purchase_order_number"])
+	items := sent["line_items"].([]interface{})
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, 24.0, items[0].(map[string]interface{})["unit_cost"])
+
+	b, err = json.Marshal(PaymentRequest{Amount: MustParseMoney("1.00")})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(b), "level_2")
+	assert.NotContains(t, string(b), "line_items")
+}
+
+func TestUnit_LineItems_Checked(t *testing.T) {
+	assert.Nil(t, checkLineItems(level3Request()))
+	assert.Nil(t, checkLineItems(PaymentRequest{Amount: MustParseMoney("5.00")}))
+
+	request := level3Request()
+	request.Amount = MustParseMoney("95.00")
+	err := checkLineItems(request).(*BeanstreamApiException)
+	assert.Equal(t, 400, err.Status)
+	assert.Equal(t, []ErrorDetail{{"amount", "is 95.00 but the line items total 89.36"}}, err.Details)
+
+	request = level3Request()
+	request.Level2.TaxAmount = MustParseMoney("9.00")
+	request.LineItems[1].Quantity = 0
+	err = checkLineItems(request).(*BeanstreamApiException)
+	assert.Equal(t, "line_items[1].quantity", err.Details[0].Field)
+	assert.Equal(t, "level_2.tax_amount", err.Details[1].Field)
+}
+
+func TestUnit_LineItems_MismatchNotSent(t *testing.T) {
+	fake := &fakeGateway{}
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+	request := level3Request()
+	request.Amount = MustParseMoney("100.00")
+	_, err := gateway.Payments().MakePayment(request)
+	assert.NotNil(t, err)
+	_, err = gateway.Payments().CompletePayment("10000001", request)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(fake.received()))
+
+	_, err = gateway.Payments().MakePayment(level3Request())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(fake.received()))
+}
@@ -0,0 +1,126 @@
+package beanstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+LogEvent describes one request to the gateway and what came back. It is
+handed to the Gateway's Logger after every attempt.
+
+Card data never reaches a Logger: card numbers are masked to their last four
+digits, CVDs, Legato tokens, 3-D Secure CAVVs and XIDs, and the Interac Online
+track data and issuer confirmation are replaced, and the Authorization
+passcode is hidden. Bodies that are not JSON, such as batch file uploads, are
+left out and only their size is given.
+*/
+type LogEvent struct {
+	Method        string
+	URL           string
+	RequestHeader http.Header
+	RequestBody   string
+	Status        int // 0 when no response was received
+	ResponseBody  string
+	Latency       time.Duration
+	Err           error // set when no response was received; see RequestException
+}
+
+// Logger receives a LogEvent for each request made through a Gateway.
+// It may be called from several goroutines at once.
+type Logger interface {
+	Log(event LogEvent)
+}
+
+// LoggerFunc lets an ordinary function be used as a Logger.
+type LoggerFunc func(event LogEvent)
+
+// Log calls f(event).
+func (f LoggerFunc) Log(event LogEvent) {
+	f(event)
+}
+
+const redacted = "[REDACTED]"
+
+// log sends a redacted LogEvent to the transport's Logger, if there is one.
+func (t transport) log(req *http.Request, reqBody []byte, status int, respBody []byte, latency time.Duration, err error) {
+	if t.logger == nil {
+		return
+	}
+	t.logger.Log(LogEvent{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: redactHeader(req.Header),
+		RequestBody:   redactBody(reqBody),
+		Status:        status,
+		ResponseBody:  redactBody(respBody),
+		Latency:       latency,
+		Err:           err})
+}
+
+// redactHeader copies h with the Authorization passcode hidden.
+func redactHeader(h http.Header) http.Header {
+	c := h.Clone()
+	if c.Get("Authorization") != "" {
+		c.Set("Authorization", "Passcode "+redacted)
+	}
+	return c
+}
+
+// redactBody returns a JSON body with its card data masked.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return fmt.Sprintf("[%v byte non-JSON body omitted]", len(body))
+	}
+	b, err := json.Marshal(redactValue("", v))
+	if err != nil {
+		return fmt.Sprintf("[%v byte body omitted]", len(body))
+	}
+	return string(b)
+}
+
+// redactValue walks a decoded JSON value. key is the name the value was found under.
+func redactValue(key string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if key == "token" && k == "code" {
+				val[k] = redacted // single-use Legato token on a payment or profile
+				continue
+			}
+			val[k] = redactValue(k, child)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(key, child)
+		}
+		return val
+	case string:
+		switch key {
+		case "number":
+			return maskCardNumber(val)
+		case "cvd", "token", "cavv", "xid", "idebit_track2", "idebit_issconf":
+			return redacted
+		}
+	}
+	return v
+}
+
+// maskCardNumber keeps only the last 4 digits of a card number.
+func maskCardNumber(number string) string {
+	if len(number) <= 4 {
+		return strings.Repeat("*", len(number))
+	}
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
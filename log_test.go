@@ -0,0 +1,82 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUnit_Log_RedactCardRequest(t *testing.T) {
+	request := PaymentRequest{
+		PaymentMethod: paymentMethods.CARD,
+		OrderNumber:   "ORDER1",
+		Amount:        MustParseMoney("12.99"),
+		Card: CreditCard{
+			Name:        "John Doe",
+			Number:      "5100000010001004",
+			ExpiryMonth: "11",
+			ExpiryYear:  "19",
+			Cvd:         "123"},
+		Token: Token{Token: "gt7-0f2f20dd-777e-487e-b688-940b526172cd", Name: "John Doe"}}
+	body, _ := json.Marshal(request)
+	logged := redactBody(body)
+	assert.False(t, strings.Contains(logged, "5100000010001004"), "Card number was logged")
+	assert.True(t, strings.Contains(logged, `"number":"************1004"`), logged)
+	assert.False(t, strings.Contains(logged, "123"), "CVD was logged")
+	assert.False(t, strings.Contains(logged, "gt7-0f2f20dd"), "Token was logged")
+	assert.True(t, strings.Contains(logged, "ORDER1"), logged)
+}
+
+func TestUnit_Log_RedactLegatoResponse(t *testing.T) {
+	logged := redactBody([]byte(`{"token":"gt7-0f2f20dd-777e-487e-b688-940b526172cd","code":1,"version":1,"message":""}`))
+	assert.False(t, strings.Contains(logged, "gt7-0f2f20dd"), "Token was logged")
+	assert.True(t, strings.Contains(logged, `"code":1`), logged)
+}
+
+func TestUnit_Log_RedactInteracResponse(t *testing.T) {
+	request := ContinueRequest{
+		PaymentMethod: paymentMethods.INTERAC,
+		InteracResponse: &InteracResponse{
+			Funded:        "1",
+			IdebitTrack2:  "3728024906540591206=01121122334455000",
+			IdebitIssConf: "CONF#TEST",
+			IdebitIssName: "TestBank1"}}
+	body, _ := json.Marshal(request)
+	logged := redactBody(body)
+	assert.False(t, strings.Contains(logged, "3728024906540591206"), "Track data was logged")
+	assert.False(t, strings.Contains(logged, "CONF#TEST"), "Issuer confirmation was logged")
+	assert.True(t, strings.Contains(logged, `"idebit_track2":"[REDACTED]"`), logged)
+	assert.True(t, strings.Contains(logged, "TestBank1"), logged)
+}
+
+func TestUnit_Log_NonJsonBodyOmitted(t *testing.T) {
+	logged := redactBody([]byte("--boundary\r\n4030000010001234,11,19\r\n"))
+	assert.False(t, strings.Contains(logged, "4030000010001234"), "Batch file was logged")
+}
+
+func TestUnit_Log_GatewayLogger(t *testing.T) {
+	var events []LogEvent
+	fake := (&fakeGateway{}).on(http.MethodPost, "/payments", 200, `{"order_number":"TEST1"}`)
+	gateway := Gateway{
+		Config:     DefaultConfig(),
+		HTTPClient: fake.client(),
+		Logger:     LoggerFunc(func(e LogEvent) { events = append(events, e) })}
+	gateway.Config.MerchantId = "300200578"
+	gateway.Config.PaymentsApiKey = "4BaD82D9197b4cc4b70a221911eE9f70"
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{Card: CreditCard{Number: "5100000010001004", Cvd: "123"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+	e := events[0]
+	assert.Equal(t, http.MethodPost, e.Method)
+	assert.Equal(t, "https://www.beanstream.com/api/v1/payments", e.URL)
+	assert.Equal(t, 200, e.Status)
+	assert.Equal(t, "Passcode "+redacted, e.RequestHeader.Get("Authorization"))
+	assert.False(t, strings.Contains(e.RequestBody, "5100000010001004"), "Card number was logged")
+	assert.True(t, strings.Contains(e.ResponseBody, "TEST1"), e.ResponseBody)
+}
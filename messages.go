@@ -0,0 +1,212 @@
+package beanstream
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TransactionType is the kind of a transaction or of an adjustment to one.
+type TransactionType string
+
+const (
+	TransactionPurchase     TransactionType = "P"   // a payment taken in full
+	TransactionPreAuth      TransactionType = "PA"  // funds reserved, to be completed later
+	TransactionCompletion   TransactionType = "PAC" // a pre-authorization was captured
+	TransactionReturn       TransactionType = "R"   // money given back to the customer
+	TransactionVoidPurchase TransactionType = "VP"  // a purchase was cancelled
+	TransactionVoidReturn   TransactionType = "VR"  // a return was cancelled
+)
+
+// ApprovalStatus says whether the gateway approved a transaction.
+type ApprovalStatus int
+
+const (
+	StatusDeclined ApprovalStatus = 0
+	StatusApproved ApprovalStatus = 1
+)
+
+func (s ApprovalStatus) String() string {
+	switch s {
+	case StatusDeclined:
+		return "declined"
+	case StatusApproved:
+		return "approved"
+	}
+	return fmt.Sprintf("status %d", int(s))
+}
+
+// MessageId identifies the gateway's message about a transaction. Declines
+// carry it in BeanstreamApiException.Code as well; see its MessageId method.
+type MessageId int
+
+// MessageKind sorts gateway messages by what the caller should do about them.
+type MessageKind int
+
+const (
+	MessageUnknown   MessageKind = iota // not in the catalogue
+	MessageApproval                     // the transaction went through
+	MessageDecline                      // the issuer refused; ask for another payment method
+	MessageHardError                    // the request is wrong and will fail again as sent
+	MessageSoftError                    // a temporary failure; the same request may succeed later
+)
+
+func (k MessageKind) String() string {
+	switch k {
+	case MessageApproval:
+		return "approval"
+	case MessageDecline:
+		return "decline"
+	case MessageHardError:
+		return "hard error"
+	case MessageSoftError:
+		return "soft error"
+	}
+	return "unknown"
+}
+
+/*
+MessageInfo describes a gateway message. Merchant is meant for logs and
+support staff; Customer is safe to show a cardholder, and never says more about
+a decline than that the payment did not go through.
+*/
+type MessageInfo struct {
+	Id       MessageId
+	Kind     MessageKind
+	Merchant string
+	Customer string
+}
+
+// Retryable reports whether the same request may succeed if sent again later.
+func (m MessageInfo) Retryable() bool {
+	return m.Kind == MessageSoftError
+}
+
+const (
+	customerApproved = "Your payment was approved."
+	customerDeclined = "Your payment was declined. Please use another payment method."
+	customerError    = "We could not process your payment. Please check your details and try again."
+	customerRetry    = "We could not process your payment right now. Please try again in a few minutes."
+)
+
+var (
+	messagesMu sync.RWMutex
+	messages   = catalogue(
+		// approvals
+		MessageInfo{1, MessageApproval, "Approved", customerApproved},
+		MessageInfo{2, MessageApproval, "Approved: check the cardholder's ID", customerApproved},
+		MessageInfo{8, MessageApproval, "Approved: VIP", customerApproved},
+
+		// declines: the issuer refused the card
+		MessageInfo{3, MessageDecline, "Declined: call the card issuer", customerDeclined},
+		MessageInfo{4, MessageDecline, "Declined: pick up the card", customerDeclined},
+		MessageInfo{5, MessageDecline, "Declined: do not honour", customerDeclined},
+		MessageInfo{6, MessageDecline, "Declined: restricted card", customerDeclined},
+		MessageInfo{7, MessageDecline, "Declined by the card issuer", customerDeclined},
+		MessageInfo{9, MessageDecline, "Declined: card reported lost", customerDeclined},
+		MessageInfo{10, MessageDecline, "Declined: card reported stolen", customerDeclined},
+		MessageInfo{11, MessageDecline, "Declined: insufficient funds", customerDeclined},
+		MessageInfo{12, MessageDecline, "Declined: card expired", customerDeclined},
+		MessageInfo{13, MessageDecline, "Declined: exceeds the card's limit", customerDeclined},
+		MessageInfo{14, MessageDecline, "Declined: invalid card number", customerDeclined},
+		MessageInfo{15, MessageDecline, "Declined: CVD mismatch", customerDeclined},
+		MessageInfo{17, MessageDecline, "Declined: transaction not permitted for this card", customerDeclined},
+		MessageInfo{18, MessageDecline, "Declined: suspected fraud", customerDeclined},
+		MessageInfo{311, MessageDecline, "Declined: 3D Secure authentication failed", customerDeclined},
+
+		// hard errors: the request will fail again as sent
+		MessageInfo{16, MessageHardError, "Duplicate transaction: this order was already approved", customerError},
+		MessageInfo{49, MessageHardError, "Invalid transaction request string", customerError},
+		MessageInfo{52, MessageHardError, "Invalid card number", customerError},
+		MessageInfo{71, MessageHardError, "Card type not accepted by this merchant account", customerError},
+		MessageInfo{76, MessageHardError, "Invalid expiry date", customerError},
+		MessageInfo{122, MessageHardError, "Invalid amount", customerError},
+		MessageInfo{194, MessageHardError, "Amount exceeds what is left to return or complete", customerError},
+		MessageInfo{208, MessageHardError, "Invalid adjustment: the transaction cannot be changed this way", customerError},
+		MessageInfo{314, MessageHardError, "Missing or invalid payment information", customerError},
+		MessageInfo{315, MessageHardError, "Invalid currency for this merchant account", customerError},
+
+		// soft errors: the same request may succeed later
+		MessageInfo{19, MessageSoftError, "Issuer unavailable: try again", customerRetry},
+		MessageInfo{20, MessageSoftError, "Issuer timed out", customerRetry},
+		MessageInfo{21, MessageSoftError, "Card network unavailable", customerRetry},
+		MessageInfo{186, MessageSoftError, "Gateway busy: try again", customerRetry},
+		MessageInfo{187, MessageSoftError, "Gateway timed out waiting for the processor", customerRetry},
+	)
+)
+
+func catalogue(infos ...MessageInfo) map[MessageId]MessageInfo {
+	m := make(map[MessageId]MessageInfo, len(infos))
+	for _, info := range infos {
+		m[info.Id] = info
+	}
+	return m
+}
+
+/*
+RegisterMessage adds a message to the catalogue, or replaces the entry for its
+Id. Errors from the gateway are classified even when their message is not
+catalogued (see BeanstreamApiException.MessageInfo); register a message to
+give it your own text or kind, so every caller of Info sees the same:
+	beanstream.RegisterMessage(beanstream.MessageInfo{
+		Id:       1001,
+		Kind:     beanstream.MessageSoftError,
+		Merchant: "Issuer unavailable",
+		Customer: "We could not process your payment right now. Please try again in a few minutes."})
+*/
+func RegisterMessage(info MessageInfo) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	messages[info.Id] = info
+}
+
+// LookupMessage finds id in the catalogue.
+func LookupMessage(id MessageId) (MessageInfo, bool) {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	info, ok := messages[id]
+	return info, ok
+}
+
+// Info describes the message. An id that is not in the catalogue gets Kind
+// MessageUnknown and generic text.
+func (id MessageId) Info() MessageInfo {
+	if info, ok := LookupMessage(id); ok {
+		return info
+	}
+	return MessageInfo{id, MessageUnknown, fmt.Sprintf("Gateway message %d", int(id)), customerError}
+}
+
+// MessageId is the gateway message behind the error, such as the reason for a
+// decline. It is only meaningful for errors the gateway sent.
+func (e *BeanstreamApiException) MessageId() MessageId {
+	return MessageId(e.Code)
+}
+
+/*
+MessageInfo describes the error for checkout and support tooling. A message in
+the catalogue keeps its entry; any other is classified by what the gateway
+said about it, so every decline is a MessageDecline and every failure on the
+gateway's side is a retryable MessageSoftError:
+	5xx status, or category 3       MessageSoftError
+	402 status, or category 1       MessageDecline
+	any other 4xx, or category 2    MessageHardError
+Merchant is then the gateway's own message.
+*/
+func (e *BeanstreamApiException) MessageInfo() MessageInfo {
+	id := e.MessageId()
+	if e.Status >= 500 {
+		return MessageInfo{id, MessageSoftError, e.Message, customerRetry}
+	}
+	if info, ok := LookupMessage(id); ok && info.Kind != MessageApproval {
+		return info
+	}
+	switch {
+	case e.Category == 3:
+		return MessageInfo{id, MessageSoftError, e.Message, customerRetry}
+	case e.Status == 402 || e.Category == 1:
+		return MessageInfo{id, MessageDecline, e.Message, customerDeclined}
+	case e.Category == 2 || (e.Status >= 400 && e.Status < 500):
+		return MessageInfo{id, MessageHardError, e.Message, customerError}
+	}
+	return MessageInfo{id, MessageUnknown, e.Message, customerError}
+}
@@ -0,0 +1,104 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnit_Messages_TypedResponse(t *testing.T) {
+	var res PaymentResponse
+	err := json.Unmarshal([]byte(`{"id":"10000001","approved":"1","message_id":"1","type":"PA"}`), &res)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, MessageId(1), res.MessageID)
+	assert.Equal(t, TransactionPreAuth, res.Type)
+	assert.True(t, res.IsApproved())
+	assert.Equal(t, MessageApproval, res.MessageID.Info().Kind)
+}
+
+func TestUnit_Messages_Catalogue(t *testing.T) {
+	decline := MessageId(7).Info()
+	assert.Equal(t, MessageDecline, decline.Kind)
+	assert.False(t, decline.Retryable())
+	assert.NotEmpty(t, decline.Customer)
+
+	unknown := MessageId(99999).Info()
+	assert.Equal(t, MessageUnknown, unknown.Kind)
+	assert.Equal(t, MessageId(99999), unknown.Id)
+	assert.Equal(t, "Gateway message 99999", unknown.Merchant)
+	_, ok := LookupMessage(99999)
+	assert.False(t, ok)
+}
+
+func TestUnit_Messages_CatalogueKinds(t *testing.T) {
+	kinds := map[MessageKind][]MessageId{
+		MessageApproval:  {1, 2, 8},
+		MessageDecline:   {3, 4, 5, 7, 11, 12, 14, 18, 311},
+		MessageHardError: {16, 49, 52, 76, 122, 194, 314},
+		MessageSoftError: {19, 20, 21, 186, 187}}
+	for kind, ids := range kinds {
+		for _, id := range ids {
+			info, ok := LookupMessage(id)
+			assert.True(t, ok, "message %d", id)
+			assert.Equal(t, id, info.Id)
+			assert.Equal(t, kind, info.Kind, "message %d", id)
+			assert.Equal(t, kind == MessageSoftError, info.Retryable(), "message %d", id)
+			assert.NotEmpty(t, info.Merchant)
+			assert.NotEmpty(t, info.Customer)
+		}
+	}
+	// a decline never tells the customer why
+	assert.Equal(t, "Declined: insufficient funds", MessageId(11).Info().Merchant)
+	assert.NotContains(t, MessageId(11).Info().Customer, "funds")
+
+	// a catalogued soft error is retryable whatever category the gateway sent
+	e := &BeanstreamApiException{400, 19, 2, "Issuer unavailable", "", nil, nil}
+	assert.True(t, e.MessageInfo().Retryable())
+}
+
+func TestUnit_Messages_Register(t *testing.T) {
+	RegisterMessage(MessageInfo{99998, MessageSoftError, "Issuer unavailable", "Try again later."})
+	info := MessageId(99998).Info()
+	assert.Equal(t, MessageSoftError, info.Kind)
+	assert.True(t, info.Retryable())
+	assert.Equal(t, "Try again later.", info.Customer)
+}
+
+func TestUnit_Messages_FromException(t *testing.T) {
+	e := &BeanstreamApiException{402, 7, 1, "DECLINE", "", nil, nil}
+	assert.Equal(t, MessageId(7), e.MessageId())
+	assert.Equal(t, MessageDecline, e.MessageId().Info().Kind)
+	assert.Equal(t, MessageDecline, e.MessageInfo().Kind)
+}
+
+func TestUnit_Messages_ExceptionKinds(t *testing.T) {
+	kinds := []struct {
+		err  *BeanstreamApiException
+		kind MessageKind
+	}{
+		// declines, catalogued or not
+		{&BeanstreamApiException{402, 7, 1, "DECLINE", "", nil, nil}, MessageDecline},
+		{&BeanstreamApiException{402, 1234, 1, "Insufficient funds", "", nil, nil}, MessageDecline},
+		{&BeanstreamApiException{402, 1235, 0, "DECLINE", "", nil, nil}, MessageDecline},
+		// hard errors
+		{&BeanstreamApiException{400, 194, 2, "Invalid amount", "", nil, nil}, MessageHardError},
+		{&BeanstreamApiException{400, 314, 2, "Missing payment information", "", nil, nil}, MessageHardError},
+		{&BeanstreamApiException{402, 16, 1, "Duplicate transaction", "", nil, nil}, MessageHardError},
+		{&BeanstreamApiException{404, 0, 0, "Not found", "", nil, nil}, MessageHardError},
+		// soft errors, even when the code collides with a catalogued approval
+		{&BeanstreamApiException{500, 1, 1, "Server error", "", nil, nil}, MessageSoftError},
+		{&BeanstreamApiException{503, 0, 0, "Unavailable", "", nil, nil}, MessageSoftError},
+		{&BeanstreamApiException{402, 1236, 3, "Issuer timed out", "", nil, nil}, MessageSoftError}}
+	for _, k := range kinds {
+		info := k.err.MessageInfo()
+		assert.Equal(t, k.kind, info.Kind, k.err.Message)
+		assert.Equal(t, k.kind == MessageSoftError, info.Retryable(), k.err.Message)
+		assert.NotEmpty(t, info.Customer)
+	}
+	info := (&BeanstreamApiException{402, 1234, 1, "Insufficient funds", "", nil, nil}).MessageInfo()
+	assert.Equal(t, "Insufficient funds", info.Merchant)
+	assert.NotContains(t, info.Customer, "funds")
+}
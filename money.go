@@ -0,0 +1,115 @@
+package beanstream
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Money is an exact amount in cents, so 12.99 is Money(1299). Use it for every
+amount sent to or received from the gateway; unlike a float it holds values
+such as 1234567.89 exactly, and sums of Money do not drift.
+
+ParseMoney reads the decimal form:
+	amount, err := beanstream.ParseMoney("12.99")
+String and JSON give it back as 12.99. When decoding, both the numeric form
+(12.99) and the quoted form ("12.99") that some API responses use are accepted.
+*/
+type Money int64
+
+// MustParseMoney is like ParseMoney but panics if s is not a valid amount.
+// It is meant for constants in code and tests.
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// ParseMoney reads a decimal amount such as "12.99", "-5" or "0.5". More than
+// two decimal places is an error unless the extra digits are zeros.
+func ParseMoney(s string) (Money, error) {
+	text := s
+	neg := false
+	if strings.HasPrefix(text, "-") {
+		neg = true
+		text = text[1:]
+	} else if strings.HasPrefix(text, "+") {
+		text = text[1:]
+	}
+	whole, frac := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		whole, frac = text[:i], text[i+1:]
+	}
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("beanstream: invalid amount %q", s)
+	}
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return 0, fmt.Errorf("beanstream: amount %q has more than 2 decimal places", s)
+		}
+		frac = frac[:2]
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	cents, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("beanstream: amount %q is out of range", s)
+	}
+	if neg {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount with two decimal places, eg 12.99 or -0.50.
+func (m Money) String() string {
+	cents := int64(m)
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON writes the amount as a JSON number, eg 12.99.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads the amount from a JSON number or a quoted string. An
+// empty string or null is zero.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(bytes.TrimSpace(data))
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = strings.TrimSpace(unquoted)
+		if text == "" {
+			*m = 0
+			return nil
+		}
+	}
+	v, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
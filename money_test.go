@@ -0,0 +1,66 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUnit_Money_Parse(t *testing.T) {
+	cases := map[string]Money{
+		"12.99":      1299,
+		"1234567.89": 123456789,
+		"5":          500,
+		"0.5":        50,
+		".05":        5,
+		"-0.50":      -50,
+		"+3.10":      310,
+		"7.000":      700}
+	for text, want := range cases {
+		m, err := ParseMoney(text)
+		assert.Nil(t, err, text)
+		assert.Equal(t, want, m, text)
+	}
+	for _, text := range []string{"", "-", ".", "1.999", "1,00", "12.9a", "1e3", "99999999999999999999"} {
+		_, err := ParseMoney(text)
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestUnit_Money_String(t *testing.T) {
+	assert.Equal(t, "12.99", Money(1299).String())
+	assert.Equal(t, "1234567.89", MustParseMoney("1234567.89").String())
+	assert.Equal(t, "0.05", Money(5).String())
+	assert.Equal(t, "-0.50", Money(-50).String())
+	assert.Equal(t, "0.00", Money(0).String())
+}
+
+func TestUnit_Money_JSON(t *testing.T) {
+	b, err := json.Marshal(voidRequest{MustParseMoney("1234567.89")})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"amount":1234567.89}`, string(b))
+
+	var tx Transaction
+	err = json.Unmarshal([]byte(`{"amount":1234567.89,"total_refunds":"10.01"}`), &tx)
+	assert.Nil(t, err)
+	assert.Equal(t, Money(123456789), tx.Amount)
+	assert.Equal(t, Money(1001), tx.TotalRefunds)
+
+	var rec TransactionRecord
+	err = json.Unmarshal([]byte(`{"trn_amount":"100.00","trn_returns":"","trn_completions":null}`), &rec)
+	assert.Nil(t, err)
+	assert.Equal(t, Money(10000), rec.Amount)
+	assert.Equal(t, Money(0), rec.Returns)
+
+	err = json.Unmarshal([]byte(`{"trn_amount":"abc"}`), &rec)
+	assert.NotNil(t, err)
+}
+
+func TestUnit_Money_VoidMatchesOriginal(t *testing.T) {
+	var tx Transaction
+	json.Unmarshal([]byte(`{"amount":"1234567.89"}`), &tx)
+	b, _ := json.Marshal(voidRequest{tx.Amount})
+	assert.Equal(t, `{"amount":1234567.89}`, string(b))
+}
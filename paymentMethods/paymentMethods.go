@@ -6,3 +6,4 @@ const CASH = "cash"
 const CHEQUE = "cheque"
 const TOKEN = "token"
 const PROFILE = "payment_profile"
+const INTERAC = "interac"
@@ -1,7 +1,9 @@
 package beanstream
 
 import (
+	"context"
 	"fmt"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
 	"net/http"
 	"time"
 )
@@ -19,7 +21,16 @@ return payments, void payments, as well as pre-authorize and complete
 payments.
 */
 type PaymentsAPI struct {
-	Config Config
+	Config       Config
+	transport    transport
+	checkReturns bool               // see Gateway.CheckReturns
+	verification VerificationPolicy // see Gateway.Verification
+	checkCards   bool               // see Gateway.CheckCards
+}
+
+// call describes a request made with the Payments API passcode.
+func (api PaymentsAPI) call(op string, httpMethod string, url string, idempotent bool) call {
+	return call{op, httpMethod, url, api.Config, PaymentsApi, idempotent}
 }
 
 /*
@@ -28,82 +39,97 @@ are just for your own record keeping.
 You must supply it a PaymentRequest that is defined in this package
 */
 func (api PaymentsAPI) MakePayment(transaction PaymentRequest) (*PaymentResponse, error) {
+	return api.MakePaymentContext(context.Background(), transaction)
+}
+
+// MakePaymentContext is MakePayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) MakePaymentContext(ctx context.Context, transaction PaymentRequest) (*PaymentResponse, error) {
+	if err := checkCurrency(api.Config, transaction.Currency); err != nil {
+		return nil, err
+	}
+	if err := checkLineItems(transaction); err != nil {
+		return nil, err
+	}
+	if api.checkCards && transaction.PaymentMethod == paymentMethods.CARD {
+		if err := checkCard(api.Config, transaction.Card); err != nil {
+			return nil, err
+		}
+	}
 	url := api.Config.BaseUrl() + paymentUrl
-	responseType := PaymentResponse{}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.PaymentsApiKey, transaction, &responseType)
+	res, err := execute[PaymentResponse](ctx, api.transport, api.call(OpMakePayment, http.MethodPost, url, false), transaction)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Printf("MakePayment result: %T %v\n", res, res)
-	pr := res.(*PaymentResponse)
-	pr.CreatedTime = AsDate(pr.created, api.Config)
-	return pr, nil
+	if err := api.verify(ctx, transaction, res); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // Complete a pre-authorized payment for some or all of the pre-authorized amount.
 func (api PaymentsAPI) CompletePayment(transId string, request PaymentRequest) (*PaymentResponse, error) {
-	url := api.Config.BaseUrl() + completionUrl
-	url = fmt.Sprintf(url, transId)
-	responseType := PaymentResponse{}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.PaymentsApiKey, request, &responseType)
-	if err != nil {
+	return api.CompletePaymentContext(context.Background(), transId, request)
+}
+
+// CompletePaymentContext is CompletePayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) CompletePaymentContext(ctx context.Context, transId string, request PaymentRequest) (*PaymentResponse, error) {
+	if err := checkCurrency(api.Config, request.Currency); err != nil {
 		return nil, err
 	}
-	pr := res.(*PaymentResponse)
-	pr.CreatedTime = AsDate(pr.created, api.Config)
-	return pr, nil
+	if err := checkLineItems(request); err != nil {
+		return nil, err
+	}
+	url := api.Config.BaseUrl() + completionUrl
+	url = fmt.Sprintf(url, transId)
+	return execute[PaymentResponse](ctx, api.transport, api.call(OpCompletePayment, http.MethodPost, url, false), request)
 }
 
 // VoidPayment cancels a payment for all of the original amount.
 // In order to void a payment you must not wait too long.
 // The amount must equal the original amount.
-func (api PaymentsAPI) VoidPayment(transId string, amount float32) (*PaymentResponse, error) {
+func (api PaymentsAPI) VoidPayment(transId string, amount Money) (*PaymentResponse, error) {
+	return api.VoidPaymentContext(context.Background(), transId, amount)
+}
+
+// VoidPaymentContext is VoidPayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) VoidPaymentContext(ctx context.Context, transId string, amount Money) (*PaymentResponse, error) {
 	url := api.Config.BaseUrl() + voidUrl
 	url = fmt.Sprintf(url, transId)
-	responseType := PaymentResponse{}
 	req := voidRequest{amount}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.PaymentsApiKey, req, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	pr := res.(*PaymentResponse)
-	pr.CreatedTime = AsDate(pr.created, api.Config)
-	return pr, nil
+	return execute[PaymentResponse](ctx, api.transport, api.call(OpVoidPayment, http.MethodPost, url, false), req)
 }
 
 // ReturnPayment returns the money to the customer for all or some of the original amount.
-func (api PaymentsAPI) ReturnPayment(transId string, amount float32) (*PaymentResponse, error) {
+// If the Gateway has CheckReturns set, an amount over Transaction.Refundable is
+// refused before it reaches the gateway.
+func (api PaymentsAPI) ReturnPayment(transId string, amount Money) (*PaymentResponse, error) {
+	return api.ReturnPaymentContext(context.Background(), transId, amount)
+}
+
+// ReturnPaymentContext is ReturnPayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) ReturnPaymentContext(ctx context.Context, transId string, amount Money) (*PaymentResponse, error) {
+	if api.checkReturns {
+		if err := api.checkRefundable(ctx, transId, amount); err != nil {
+			return nil, err
+		}
+	}
 	url := api.Config.BaseUrl() + returnUrl
 	url = fmt.Sprintf(url, transId)
-	responseType := PaymentResponse{}
 	req := returnRequest{amount}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.PaymentsApiKey, req, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	pr := res.(*PaymentResponse)
-	pr.CreatedTime = AsDate(pr.created, api.Config)
-	return pr, nil
+	return execute[PaymentResponse](ctx, api.transport, api.call(OpReturnPayment, http.MethodPost, url, false), req)
 }
 
 // GetTransaction retrieves a transaction and all adjustments that were performed on it.
 func (api PaymentsAPI) GetTransaction(transId string) (*Transaction, error) {
+	return api.GetTransactionContext(context.Background(), transId)
+}
+
+// GetTransactionContext is GetTransaction with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) GetTransactionContext(ctx context.Context, transId string) (*Transaction, error) {
 	url := api.Config.BaseUrl() + getPaymentUrl
 	url = fmt.Sprintf(url, transId)
 
-	responseType := Transaction{}
-	res, err := Process(http.MethodGet, url, api.Config.MerchantId, api.Config.PaymentsApiKey, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	pr := res.(*Transaction)
-	pr.CreatedTime = AsDate(pr.created, api.Config)
-	if pr.Adjustments != nil {
-		for _, adj := range pr.Adjustments {
-			adj.CreatedTime = AsDate(adj.created, api.Config)
-		}
-	}
-	return pr, nil
+	return execute[Transaction](ctx, api.transport, api.call(OpGetTransaction, http.MethodGet, url, true), nil)
 }
 
 // PaymentRequest is the main struct for making a payment. The mandatory fields are:
@@ -114,7 +140,7 @@ func (api PaymentsAPI) GetTransaction(transId string) (*Transaction, error) {
 type PaymentRequest struct {
 	PaymentMethod   string         `json:"payment_method"`
 	OrderNumber     string         `json:"order_number,omitempty"`
-	Amount          float32        `json:"amount"`
+	Amount          Money          `json:"amount"`
 	Card            CreditCard     `json:"card,omitempty"`
 	Token           Token          `json:"token,omitempty"`
 	Profile         ProfilePayment `json:"payment_profile,omitempty"`
@@ -123,24 +149,36 @@ type PaymentRequest struct {
 	Comment         string         `json:"comments,omitempty"`
 	Language        string         `json:"language,omitempty"`
 	CustomerIp      string         `json:"customer_ip,omitempty"`
-	TermUrl         string         `json:"term_url,omitempty"`
+	TermUrl         string         `json:"term_url,omitempty"` // where the issuer returns the customer after a redirect; see ContinuePayment
 	Custom          CustomFields   `json:"custom,omitempty"`
+
+	// Level 2 and Level 3 purchase data for business cards. The line items
+	// must add up to Amount; see LineItem.
+	Level2    *Level2    `json:"level_2,omitempty"`
+	LineItems []LineItem `json:"line_items,omitempty"`
+
+	// Currency of Amount. The gateway always charges in the merchant's
+	// settlement currency, so it is not sent; if Config.Currency is set it
+	// must be set to match, or the payment is rejected.
+	Currency Currency `json:"-"`
 }
 
 // CreditCard info for making a payment.
 // You can pre-authorize a purchase by setting Complete to false.
 type CreditCard struct {
-	Name        string `json:"name"`
-	Number      string `json:"number"`
-	ExpiryMonth string `json:"expiry_month"`
-	ExpiryYear  string `json:"expiry_year"`
-	Cvd         string `json:"cvd"`
-	Complete    bool   `json:"complete"`
-	Function    string `json:"function,omitempty"`
-	Type        string `json:"card_type,omitempty"`
-	Id          int    `json:"card_id,string,omitempty"`
-	AvsResult   string `json:"avs_result,omitempty"`
-	CvdResult   string `json:"cvd_result,omitempty"`
+	Name        string    `json:"name"`
+	Number      string    `json:"number"`
+	ExpiryMonth string    `json:"expiry_month"`
+	ExpiryYear  string    `json:"expiry_year"`
+	Cvd         string    `json:"cvd"`
+	Complete    bool      `json:"complete"`
+	Function    string    `json:"function,omitempty"`
+	Type        string    `json:"card_type,omitempty"`
+	Id          int       `json:"card_id,string,omitempty"`
+	AvsResult   AvsResult `json:"avs_result,omitempty"`
+	CvdResult   CvdResult `json:"cvd_result,omitempty"`
+
+	ThreeDSecure *ThreeDSecure `json:"3d_secure,omitempty"` // for card payments only; see ThreeDSecure
 }
 
 // Token is a single-use Legato token for making a payment.
@@ -162,16 +200,16 @@ type ProfilePayment struct {
 }
 
 type completionRequest struct {
-	Amount float32      `json:"amount"`
+	Amount Money        `json:"amount"`
 	Custom CustomFields `json:"custom"`
 }
 
 type voidRequest struct {
-	Amount float32 `json:"amount"`
+	Amount Money `json:"amount"`
 }
 
 type returnRequest struct {
-	Amount float32 `json:"amount"`
+	Amount Money `json:"amount"`
 }
 
 // Address is either a billing or shipping address
@@ -201,47 +239,66 @@ Transaction is the struct you receive when you call GetTransaction().
 To check if a transaction is approved you can call the method IsApproved()
 */
 type Transaction struct {
-	Id               int    `json:"id,omitempty"`
-	Approved         int    `json:"approved,omitempty"`
-	MessageId        int    `json:"message_id,omitempty"`
-	Message          string `json:"message,omitempty"`
-	AuthCode         string `json:"auth_code,omitempty"`
-	created          string `json:"created,omitempty"`
+	Id               int            `json:"id,omitempty"`
+	Approved         ApprovalStatus `json:"approved,omitempty"`
+	MessageId        MessageId      `json:"message_id,omitempty"`
+	Message          string         `json:"message,omitempty"`
+	AuthCode         string         `json:"auth_code,omitempty"`
+	created          string         // parsed into CreatedTime
 	CreatedTime      time.Time
-	OrderNumber      string       `json:"order_number,omitempty"`
-	Amount           float32      `json:"amount,omitempty"`
-	Type             string       `json:"type,omitempty"`
-	Comment          string       `json:"comments,omitempty"`
-	BatchNumber      string       `json:"batch_number,omitempty"`
-	TotalRefunds     float32      `json:"total_refunds,omitempty"`
-	TotalCompletions float32      `json:"total_completions,omitempty"`
-	PaymentMethod    string       `json:"payment_method,omitempty"`
-	Card             CreditCard   `json:"card,omitempty"`
-	BillingAddress   Address      `json:"billing,omitempty"`
-	ShippingAddress  Address      `json:"shipping,omitempty"`
-	Custom           CustomFields `json:"custom,omitempty"`
-	Adjustments      []Adjustment `json:"adjusted_by,omitempty"`
-	Links            []Link       `json:"links,omitempty"`
+	OrderNumber      string          `json:"order_number,omitempty"`
+	Amount           Money           `json:"amount,omitempty"`
+	Type             TransactionType `json:"type,omitempty"`
+	Comment          string          `json:"comments,omitempty"`
+	BatchNumber      string          `json:"batch_number,omitempty"`
+	TotalRefunds     Money           `json:"total_refunds,omitempty"`
+	TotalCompletions Money           `json:"total_completions,omitempty"`
+	PaymentMethod    string          `json:"payment_method,omitempty"`
+	Card             CreditCard      `json:"card,omitempty"`
+	BillingAddress   Address         `json:"billing,omitempty"`
+	ShippingAddress  Address         `json:"shipping,omitempty"`
+	Custom           CustomFields    `json:"custom,omitempty"`
+	Adjustments      []Adjustment    `json:"adjusted_by,omitempty"`
+	Links            []Link          `json:"links,omitempty"`
+	Currency         Currency        `json:"-"` // the Config's settlement currency
+	Response         *ResponseInfo   `json:"-"` // set when Gateway.CaptureResponses is on
+	DateErr          error           `json:"-"` // why a CreatedTime was left zero, if one could not be parsed
+}
+
+// afterDecode fills in the parsed creation times and the currency.
+func (t *Transaction) afterDecode(config Config) {
+	t.Currency = config.Currency
+	var err error
+	if t.CreatedTime, err = ParseDate(t.created, config); err != nil {
+		t.DateErr = fmt.Errorf("transaction %v created: %v", t.Id, err)
+	}
+	for i := range t.Adjustments {
+		adj := &t.Adjustments[i]
+		if adj.CreatedTime, err = ParseDate(adj.created, config); err != nil && t.DateErr == nil {
+			t.DateErr = fmt.Errorf("adjustment %v created: %v", adj.Id, err)
+		}
+	}
 }
 
 // IsApproved will test if a Payment was approved
 func (t *Transaction) IsApproved() bool {
-	if t.Approved == 1 {
+	if t.Approved == StatusApproved {
 		return true
 	}
 	return false
 }
 
 /*
-Adjustment to a payment, often a return or void.
+Adjustment to a payment, often a return or void. Type says which; call
+IsApproved to see if the gateway accepted it.
 */
 type Adjustment struct {
-	Id          int     `json:"id,omitempty"`
-	Type        string  `json:"type,omitempty"`
-	Approval    int     `json:"approval,omitempty"`
-	Message     string  `json:"message,omitempty"`
-	Amount      float32 `json:"amount,omitempty"`
-	created     string  `json:"created,omitempty"`
+	Id          int            `json:"id,omitempty"`
+	Type        AdjustmentType `json:"type,omitempty"`
+	Approval    ApprovalStatus `json:"approval,omitempty"`
+	Message     string         `json:"message,omitempty"`
+	Amount      Money          `json:"amount,omitempty"`
+	created     string         // parsed into CreatedTime
 	CreatedTime time.Time
 	Url         string `json:"url,omitempty"`
 }
@@ -259,16 +316,16 @@ type Link struct {
 // PaymentResponse is the response from a successful transaction. Some fields might be empty.
 // To check if a transaction is approved you can call the method IsApproved().
 type PaymentResponse struct {
-	Approved int    `json:"approved,string"`
-	AuthCode string `json:"auth_code"`
+	Approved ApprovalStatus `json:"approved,string"`
+	AuthCode string         `json:"auth_code"`
 	Card     struct {
-		AddressMatch int    `json:"address_match"`
-		CardType     string `json:"card_type"`
-		CvdMatch     int    `json:"cvd_match"`
-		LastFour     string `json:"last_four"`
-		PostalResult int    `json:"postal_result"`
+		AddressMatch AvsMatch  `json:"address_match"`
+		CardType     string    `json:"card_type"`
+		CvdMatch     CvdResult `json:"cvd_match"`
+		LastFour     string    `json:"last_four"`
+		PostalResult AvsMatch  `json:"postal_result"`
 	} `json:"card"`
-	created     string `json:"created,omitempty"`
+	created     string // parsed into CreatedTime
 	CreatedTime time.Time
 	ID          string `json:"id"`
 	Links       []struct {
@@ -276,11 +333,14 @@ type PaymentResponse struct {
 		Method string `json:"method"`
 		Rel    string `json:"rel"`
 	} `json:"links"`
-	Message       string `json:"message"`
-	MessageID     int    `json:"message_id,string"`
-	OrderNumber   string `json:"order_number"`
-	PaymentMethod string `json:"payment_method"`
-	Type          string `json:"type"`
+	Message       string          `json:"message"`
+	MessageID     MessageId       `json:"message_id,string"`
+	OrderNumber   string          `json:"order_number"`
+	PaymentMethod string          `json:"payment_method"`
+	Type          TransactionType `json:"type"`
+	Currency      Currency        `json:"-"` // the Config's settlement currency
+	Response      *ResponseInfo   `json:"-"` // set when Gateway.CaptureResponses is on
+	DateErr       error           `json:"-"` // why CreatedTime was left zero, if it could not be parsed
 }
 
 // Example json for PaymentResponse
@@ -305,9 +365,18 @@ type PaymentResponse struct {
 //	]
 //}
 
+// afterDecode fills in the parsed creation time and the currency.
+func (t *PaymentResponse) afterDecode(config Config) {
+	t.Currency = config.Currency
+	var err error
+	if t.CreatedTime, err = ParseDate(t.created, config); err != nil {
+		t.DateErr = fmt.Errorf("payment %v created: %v", t.ID, err)
+	}
+}
+
 // IsApproved will test if a Payment was approved
 func (t *PaymentResponse) IsApproved() bool {
-	if t.Approved == 1 {
+	if t.Approved == StatusApproved {
 		return true
 	}
 	return false
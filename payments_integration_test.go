@@ -14,7 +14,7 @@ func TestIntegration_Payments_MakePayment(t *testing.T) {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.CARD,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        12.99,
+		Amount:        MustParseMoney("12.99"),
 		Card: CreditCard{
 			Name:        "John Doe",
 			Number:      "5100000010001004",
@@ -26,7 +26,7 @@ func TestIntegration_Payments_MakePayment(t *testing.T) {
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
 	assert.Equal(t, true, res.IsApproved())
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, TransactionPurchase, res.Type)
 }
 
 func TestIntegration_Payments_MakePaymentDefaultConfig(t *testing.T) {
@@ -36,11 +36,11 @@ func TestIntegration_Payments_MakePaymentDefaultConfig(t *testing.T) {
 	config.ProfilesApiKey = "D97D3BE1EE964A6193D17A571D9FBC80"
 	config.ReportingApiKey = "4e6Ff318bee64EA391609de89aD4CF5d"
 
-	gateway := Gateway{config}
+	gateway := Gateway{Config: config}
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.CARD,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        12.99,
+		Amount:        MustParseMoney("12.99"),
 		Card: CreditCard{
 			Name:        "John Doe",
 			Number:      "5100000010001004",
@@ -58,7 +58,7 @@ func TestIntegration_Payments_MakePaymentFullDetails(t *testing.T) {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.CARD,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        12.99,
+		Amount:        MustParseMoney("12.99"),
 		Card: CreditCard{
 			Name:        "John Doe",
 			Number:      "5100000010001004",
@@ -98,8 +98,8 @@ func TestIntegration_Payments_MakePaymentFullDetails(t *testing.T) {
 	res, err := gateway.Payments().MakePayment(request) //returns a pointer to PaymentResponse
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 }
 
 func TestIntegration_Payments_PreAuthComplete(t *testing.T) {
@@ -110,16 +110,16 @@ func TestIntegration_Payments_PreAuthComplete(t *testing.T) {
 	res, err := gateway.Payments().MakePayment(request)
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "PA", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPreAuth, res.Type)
 
-	request.Amount = 5.67 // lower the amount
+	request.Amount = MustParseMoney("5.67") // lower the amount
 	request.Custom.Ref1 = "Gas purchase"
 	res2, err2 := gateway.Payments().CompletePayment(res.ID, request)
 	assert.Nil(t, err2, "Unexpected error occurred.", err2)
 	assert.NotNil(t, res2, "Result was nil")
-	assert.Equal(t, 1, res2.Approved)
-	assert.Equal(t, "PAC", res2.Type)
+	assert.Equal(t, StatusApproved, res2.Approved)
+	assert.Equal(t, TransactionCompletion, res2.Type)
 }
 
 func TestIntegration_Payments_Void(t *testing.T) {
@@ -128,14 +128,14 @@ func TestIntegration_Payments_Void(t *testing.T) {
 	res, err := gateway.Payments().MakePayment(request)
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 
-	res2, err2 := gateway.Payments().VoidPayment(res.ID, 12.99)
+	res2, err2 := gateway.Payments().VoidPayment(res.ID, MustParseMoney("12.99"))
 	assert.Nil(t, err2, "Unexpected error occurred.", err2)
 	assert.NotNil(t, res2, "Result was nil")
-	assert.Equal(t, 1, res2.Approved)
-	assert.Equal(t, "VP", res2.Type)
+	assert.Equal(t, StatusApproved, res2.Approved)
+	assert.Equal(t, TransactionVoidPurchase, res2.Type)
 }
 
 func TestIntegration_Payments_Return(t *testing.T) {
@@ -144,14 +144,14 @@ func TestIntegration_Payments_Return(t *testing.T) {
 	res, err := gateway.Payments().MakePayment(request)
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 
-	res2, err2 := gateway.Payments().ReturnPayment(res.ID, 12.00)
+	res2, err2 := gateway.Payments().ReturnPayment(res.ID, MustParseMoney("12.00"))
 	assert.Nil(t, err2, "Unexpected error occurred.", err2)
 	assert.NotNil(t, res2, "Result was nil")
-	assert.Equal(t, 1, res2.Approved)
-	assert.Equal(t, "R", res2.Type)
+	assert.Equal(t, StatusApproved, res2.Approved)
+	assert.Equal(t, TransactionReturn, res2.Type)
 }
 
 func TestIntegration_Payments_ReturnError(t *testing.T) {
@@ -160,11 +160,11 @@ func TestIntegration_Payments_ReturnError(t *testing.T) {
 	res, err := gateway.Payments().MakePayment(request)
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 
 	// return more than we charged so we get an error
-	res2, err2 := gateway.Payments().ReturnPayment(res.ID, 105.00)
+	res2, err2 := gateway.Payments().ReturnPayment(res.ID, MustParseMoney("105.00"))
 	assert.Nil(t, res2, "Did not expect a proper result", res2)
 	assert.NotNil(t, err2, "Error was nil and shouldn't have been")
 	bicError := err2.(*BeanstreamApiException)
@@ -189,7 +189,7 @@ func TestIntegration_Payments_Token(t *testing.T) {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.TOKEN,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        15.99,
+		Amount:        MustParseMoney("15.99"),
 		Token: Token{
 			token,
 			"John Doe",
@@ -197,8 +197,8 @@ func TestIntegration_Payments_Token(t *testing.T) {
 	res, err2 := gateway.Payments().MakePayment(request)
 	assert.Nil(t, err2, "Unexpected error occurred.", err2)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 
 }
 
@@ -218,7 +218,7 @@ func TestIntegration_Payments_TokenPreAuth(t *testing.T) {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.TOKEN,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        50.00,
+		Amount:        MustParseMoney("50.00"),
 		Token: Token{
 			token,
 			"John Doe",
@@ -226,15 +226,15 @@ func TestIntegration_Payments_TokenPreAuth(t *testing.T) {
 	res, err2 := gateway.Payments().MakePayment(request)
 	assert.Nil(t, err2, "Unexpected error occurred.", err2)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "PA", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPreAuth, res.Type)
 
-	request.Amount = 12.01
+	request.Amount = MustParseMoney("12.01")
 	res2, err2 := gateway.Payments().CompletePayment(res.ID, request)
 	assert.Nil(t, err2, "Unexpected error occurred.", err2)
 	assert.NotNil(t, res2, "Result was nil")
-	assert.Equal(t, 1, res2.Approved)
-	assert.Equal(t, "PAC", res2.Type)
+	assert.Equal(t, StatusApproved, res2.Approved)
+	assert.Equal(t, TransactionCompletion, res2.Type)
 }
 
 func TestIntegration_Payments_Cash(t *testing.T) {
@@ -242,12 +242,12 @@ func TestIntegration_Payments_Cash(t *testing.T) {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.CASH,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        12.00}
+		Amount:        MustParseMoney("12.00")}
 	res, err := gateway.Payments().MakePayment(request) //returns a pointer to PaymentResponse
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 }
 
 func TestIntegration_Payments_Cheque(t *testing.T) {
@@ -255,12 +255,12 @@ func TestIntegration_Payments_Cheque(t *testing.T) {
 	request := PaymentRequest{
 		PaymentMethod: paymentMethods.CHEQUE,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        15.01}
+		Amount:        MustParseMoney("15.01")}
 	res, err := gateway.Payments().MakePayment(request) //returns a pointer to PaymentResponse
 	assert.Nil(t, err, "Unexpected error occurred.", err)
 	assert.NotNil(t, res, "Result was nil")
-	assert.Equal(t, 1, res.Approved)
-	assert.Equal(t, "P", res.Type)
+	assert.Equal(t, StatusApproved, res.Approved)
+	assert.Equal(t, TransactionPurchase, res.Type)
 }
 
 func TestIntegration_Payments_GetTransaction(t *testing.T) {
@@ -281,8 +281,8 @@ func TestIntegration_Payments_GetTransactionWithAdjustments(t *testing.T) {
 	res, err := gateway.Payments().MakePayment(request)
 	transId := res.ID
 
-	gateway.Payments().ReturnPayment(transId, 1.00) // a small return so we can see it in the transaction
-	gateway.Payments().VoidPayment(transId, 5.00)
+	gateway.Payments().ReturnPayment(transId, MustParseMoney("1.00")) // a small return so we can see it in the transaction
+	gateway.Payments().VoidPayment(transId, MustParseMoney("5.00"))
 
 	trans, err := gateway.Payments().GetTransaction(transId)
 	assert.Nil(t, err)
@@ -0,0 +1,248 @@
+package beanstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPreAuthValidity is how long a PreAuth is assumed to hold funds. Card
+// issuers commonly release an uncompleted authorization after about a week;
+// ask your processor for the exact figure and set PreAuth.Validity to it.
+const DefaultPreAuthValidity = 7 * 24 * time.Hour
+
+/*
+PreAuth tracks a pre-authorized payment, which reserves an amount on the
+customer's card until it is completed or released:
+	preAuth, err := gateway.Payments().PreAuthorize(request)
+	...
+	res, err := preAuth.Complete(beanstream.MustParseMoney("45.00"))
+The gateway closes a pre-authorization once it is completed, so it can be
+completed only once, for any amount up to what was authorized; the rest is
+released to the customer. Complete refuses a second completion, or more than
+Remaining, before anything is sent. Use LoadPreAuth to pick up a
+pre-authorization made earlier, for example by another process.
+
+A PreAuth is safe for concurrent use; Complete and Release run one at a time.
+Refresh updates the exported fields, so while it may be running read them
+through Remaining, Age and ExpiresAt instead.
+*/
+type PreAuth struct {
+	Id          string
+	OrderNumber string
+	Authorized  Money         // the amount reserved on the card
+	Created     time.Time     // when the gateway authorized it
+	Validity    time.Duration // how long the authorization holds; DefaultPreAuthValidity unless set
+
+	api       PaymentsAPI
+	mu        sync.Mutex
+	completed Money
+	closed    bool // completed or released
+	released  bool
+}
+
+/*
+PreAuthorize makes request as a pre-authorization, whatever its Complete flags
+say, and returns a PreAuth to complete or release it. If the authorization
+fails the Gateway's VerificationPolicy, the PreAuth is returned along with the
+*VerificationException: released, unless VoidErr says why it could not be.
+*/
+func (api PaymentsAPI) PreAuthorize(request PaymentRequest) (*PreAuth, error) {
+	return api.PreAuthorizeContext(context.Background(), request)
+}
+
+// PreAuthorizeContext is PreAuthorize with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) PreAuthorizeContext(ctx context.Context, request PaymentRequest) (*PreAuth, error) {
+	request.Card.Complete = false
+	request.Token.Complete = false
+	request.Profile.Complete = false
+	res, err := api.MakePaymentContext(ctx, request)
+	var verr *VerificationException
+	if errors.As(err, &verr) {
+		res = verr.Payment
+	}
+	if res == nil {
+		return nil, err
+	}
+	p := &PreAuth{
+		Id:          res.ID,
+		OrderNumber: res.OrderNumber,
+		Authorized:  request.Amount,
+		Created:     res.CreatedTime,
+		Validity:    DefaultPreAuthValidity,
+		api:         api}
+	if verr != nil && verr.VoidErr == nil {
+		p.closed, p.released = true, true
+	}
+	return p, err
+}
+
+// LoadPreAuth looks up a pre-authorization and how much of it was completed.
+// It fails if transId is not a pre-authorization.
+func (api PaymentsAPI) LoadPreAuth(transId string) (*PreAuth, error) {
+	return api.LoadPreAuthContext(context.Background(), transId)
+}
+
+// LoadPreAuthContext is LoadPreAuth with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) LoadPreAuthContext(ctx context.Context, transId string) (*PreAuth, error) {
+	p := &PreAuth{Id: transId, Validity: DefaultPreAuthValidity, api: api}
+	if err := p.RefreshContext(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Completed is the amount captured so far.
+func (p *PreAuth) Completed() Money {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.completed
+}
+
+// Remaining is the amount that can still be captured, which is zero once the
+// pre-authorization has been completed or released.
+func (p *PreAuth) Remaining() Money {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.remaining()
+}
+
+func (p *PreAuth) remaining() Money {
+	if p.closed {
+		return 0
+	}
+	return p.Authorized
+}
+
+// Released reports whether the pre-authorization was released without being
+// completed.
+func (p *PreAuth) Released() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.released
+}
+
+// Age is the time since the gateway authorized the payment.
+func (p *PreAuth) Age() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Since(p.Created)
+}
+
+// ExpiresAt is when the authorization is expected to lapse.
+func (p *PreAuth) ExpiresAt() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Created.Add(p.Validity)
+}
+
+// Expired reports whether ExpiresAt has passed.
+func (p *PreAuth) Expired() bool {
+	return time.Now().After(p.ExpiresAt())
+}
+
+/*
+Complete captures amount, which may be less than was authorized, and closes
+the pre-authorization. It is refused without contacting the gateway if amount
+is not positive or is more than Remaining, or the pre-authorization was
+already completed or released.
+*/
+func (p *PreAuth) Complete(amount Money) (*PaymentResponse, error) {
+	return p.CompleteContext(context.Background(), amount)
+}
+
+// CompleteContext is Complete with a context. Cancelling ctx aborts the request.
+func (p *PreAuth) CompleteContext(ctx context.Context, amount Money) (*PaymentResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkOpen(); err != nil {
+		return nil, err
+	}
+	if amount <= 0 || amount > p.remaining() {
+		return nil, &BeanstreamApiException{400, 0, 0, fmt.Sprintf("cannot complete %v of pre-authorization %v, %v remains", amount, p.Id, p.remaining()), "", nil, nil}
+	}
+	res, err := p.api.CompletePaymentContext(ctx, p.Id, PaymentRequest{Amount: amount, Currency: p.api.Config.Currency})
+	if err != nil {
+		return nil, err
+	}
+	p.completed = amount
+	p.closed = true
+	return res, nil
+}
+
+// checkOpen refuses to act on a pre-authorization that is already closed.
+func (p *PreAuth) checkOpen() error {
+	switch {
+	case p.released:
+		return &BeanstreamApiException{400, 0, 0, fmt.Sprintf("pre-authorization %v was released", p.Id), "", nil, nil}
+	case p.closed:
+		return &BeanstreamApiException{400, 0, 0, fmt.Sprintf("pre-authorization %v was already completed", p.Id), "", nil, nil}
+	}
+	return nil
+}
+
+// Release frees the reserved funds on the card. The gateway has no void for a
+// pre-authorization; it is released by completing it for 0.
+func (p *PreAuth) Release() (*PaymentResponse, error) {
+	return p.ReleaseContext(context.Background())
+}
+
+// ReleaseContext is Release with a context. Cancelling ctx aborts the request.
+func (p *PreAuth) ReleaseContext(ctx context.Context) (*PaymentResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkOpen(); err != nil {
+		return nil, err
+	}
+	res, err := p.api.releasePreAuth(ctx, p.Id)
+	if err != nil {
+		return nil, err
+	}
+	p.closed = true
+	p.released = true
+	return res, nil
+}
+
+// releasePreAuth frees the funds held by pre-authorization id. The gateway
+// refuses to void a pre-authorization, so it is completed for 0 instead.
+func (api PaymentsAPI) releasePreAuth(ctx context.Context, id string) (*PaymentResponse, error) {
+	return api.CompletePaymentContext(ctx, id, PaymentRequest{Amount: 0, Currency: api.Config.Currency})
+}
+
+// Refresh reloads the authorized and completed amounts from the gateway, to
+// take in a completion or release made elsewhere.
+func (p *PreAuth) Refresh() error {
+	return p.RefreshContext(context.Background())
+}
+
+// RefreshContext is Refresh with a context. Cancelling ctx aborts the request.
+func (p *PreAuth) RefreshContext(ctx context.Context) error {
+	tx, err := p.api.GetTransactionContext(ctx, p.Id)
+	if err != nil {
+		return err
+	}
+	if tx.Type != TransactionPreAuth {
+		return &BeanstreamApiException{400, 0, 0, fmt.Sprintf("transaction %v is not a pre-authorization", p.Id), "", nil, nil}
+	}
+	closed, released := tx.TotalCompletions > 0, false
+	for _, adj := range tx.Adjustments {
+		if adj.Type == AdjustmentCompletion && adj.IsApproved() {
+			closed, released = true, adj.Amount == 0
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.OrderNumber = tx.OrderNumber
+	p.Authorized = tx.Amount
+	p.Created = tx.CreatedTime
+	// a pre-authorization never reopens, so tx, which may have been read before
+	// a Complete or Release that ran meanwhile, can only close it
+	if !p.closed && closed {
+		p.completed = tx.TotalCompletions
+		p.closed, p.released = true, released
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	approvedPreAuth    = `{"id":"10000001","approved":"1","type":"PA","order_number":"ORDER1","created":"2016-01-02T03:04:05"}`
+	approvedCompletion = `{"id":"10000002","approved":"1","type":"PAC","order_number":"ORDER1","created":"2016-01-02T03:04:05"}`
+	alreadyCompleted   = `{"code":194,"category":2,"message":"Amount exceeds what is left to return or complete"}`
+	openPreAuth        = `{"id":10000001,"order_number":"ORDER1","amount":100.00,"type":"PA","created":"2016-01-02T03:04:05"}`
+)
+
+func TestUnit_PreAuth_Complete(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/completions", 200, approvedCompletion).
+		on(http.MethodPost, "/completions", 400, alreadyCompleted).
+		on(http.MethodPost, "/payments", 200, approvedPreAuth)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	request := PaymentRequest{Amount: MustParseMoney("100.00"), Card: CreditCard{Number: "4030000010001234", Complete: true}}
+	preAuth, err := gateway.Payments().PreAuthorize(request)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(fake.received()[0].Body, `"complete":false`))
+	assert.Equal(t, "10000001", preAuth.Id)
+	assert.Equal(t, MustParseMoney("100.00"), preAuth.Authorized)
+	assert.Equal(t, MustParseMoney("100.00"), preAuth.Remaining())
+	assert.Equal(t, 2016, preAuth.Created.Year())
+	assert.True(t, preAuth.Age() > 0)
+	assert.True(t, preAuth.Expired())
+
+	// over-completion is refused without a request
+	_, err = preAuth.Complete(MustParseMoney("100.01"))
+	assert.Equal(t, 400, err.(*BeanstreamApiException).Status)
+	_, err = preAuth.Complete(0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(fake.received()))
+
+	// a partial completion closes the pre-authorization
+	_, err = preAuth.Complete(MustParseMoney("60.00"))
+	assert.Nil(t, err)
+	assert.Equal(t, "POST /api/v1/payments/10000001/completions", fake.calls()[1])
+	assert.True(t, strings.Contains(fake.received()[1].Body, `"amount":60`))
+	assert.Equal(t, MustParseMoney("60.00"), preAuth.Completed())
+	assert.Equal(t, Money(0), preAuth.Remaining())
+	assert.False(t, preAuth.Released())
+
+	_, err = preAuth.Complete(MustParseMoney("40.00"))
+	assert.Equal(t, 400, err.(*BeanstreamApiException).Status)
+	_, err = preAuth.Release()
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, len(fake.received()))
+}
+
+func TestUnit_PreAuth_Release(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodGet, "/payments/10000001", 200, openPreAuth).
+		on(http.MethodPost, "/void", 400, `{"code":208,"category":2,"message":"Invalid adjustment: the transaction cannot be changed this way"}`).
+		on(http.MethodPost, "/completions", 200, approvedCompletion).
+		on(http.MethodPost, "/completions", 400, alreadyCompleted)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+	preAuth, err := gateway.Payments().LoadPreAuth("10000001")
+	assert.Nil(t, err)
+
+	// released by completing for 0, never by a void
+	_, err = preAuth.Release()
+	assert.Nil(t, err)
+	assert.Equal(t, "POST /api/v1/payments/10000001/completions", fake.calls()[1])
+	assert.True(t, strings.Contains(fake.received()[1].Body, `"amount":0`))
+	assert.Equal(t, 0, fake.count(http.MethodPost, "/void"))
+	assert.True(t, preAuth.Released())
+	assert.Equal(t, Money(0), preAuth.Remaining())
+
+	_, err = preAuth.Complete(MustParseMoney("1.00"))
+	assert.NotNil(t, err)
+	_, err = preAuth.Release()
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, len(fake.received()))
+}
+
+func TestUnit_PreAuth_Load(t *testing.T) {
+	fake := (&fakeGateway{}).on(http.MethodGet, "/payments/10000001", 200, openPreAuth)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	preAuth, err := gateway.Payments().LoadPreAuth("10000001")
+	assert.Nil(t, err)
+	assert.Equal(t, "GET /api/v1/payments/10000001", fake.calls()[0])
+	assert.Equal(t, "ORDER1", preAuth.OrderNumber)
+	assert.Equal(t, MustParseMoney("100.00"), preAuth.Authorized)
+	assert.Equal(t, Money(0), preAuth.Completed())
+	assert.Equal(t, MustParseMoney("100.00"), preAuth.Remaining())
+	assert.Equal(t, time.Date(2016, 1, 9, 3, 4, 5, 0, time.UTC), preAuth.ExpiresAt().UTC())
+}
+
+func TestUnit_PreAuth_LoadClosed(t *testing.T) {
+	completed := `{"id":10000001,"amount":100.00,"total_completions":40.00,"type":"PA","created":"2016-01-02T03:04:05",
+		"adjusted_by":[{"id":10000002,"type":"PAC","approval":1,"amount":40.00}]}`
+	preAuth, err := (&Gateway{Config: DefaultConfig(), HTTPClient: (&fakeGateway{}).on(http.MethodGet, "", 200, completed).client()}).Payments().LoadPreAuth("10000001")
+	assert.Nil(t, err)
+	assert.Equal(t, MustParseMoney("40.00"), preAuth.Completed())
+	assert.Equal(t, Money(0), preAuth.Remaining())
+	assert.False(t, preAuth.Released())
+
+	released := `{"id":10000001,"amount":100.00,"type":"PA","created":"2016-01-02T03:04:05",
+		"adjusted_by":[{"id":10000002,"type":"PAC","approval":1,"amount":0}]}`
+	preAuth, err = (&Gateway{Config: DefaultConfig(), HTTPClient: (&fakeGateway{}).on(http.MethodGet, "", 200, released).client()}).Payments().LoadPreAuth("10000001")
+	assert.Nil(t, err)
+	assert.True(t, preAuth.Released())
+	assert.Equal(t, Money(0), preAuth.Remaining())
+}
+
+func TestUnit_PreAuth_LoadPurchaseRefused(t *testing.T) {
+	purchase := `{"id":10000001,"approved":1,"amount":100.00,"type":"P","created":"2016-01-02T03:04:05"}`
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: (&fakeGateway{}).on(http.MethodGet, "", 200, purchase).client()}
+	preAuth, err := gateway.Payments().LoadPreAuth("10000001")
+	assert.Nil(t, preAuth)
+	assert.Equal(t, 400, err.(*BeanstreamApiException).Status)
+}
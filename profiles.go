@@ -1,6 +1,7 @@
 package beanstream
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -20,101 +21,112 @@ payments. Profiles have standard CRUD operations available to them
 as well as the ability to add more credit cards to the profile.
 */
 type ProfilesAPI struct {
-	Config Config
+	Config     Config
+	transport  transport
+	checkCards bool // see Gateway.CheckCards
+}
+
+// call describes a request made with the Profiles API passcode.
+func (api ProfilesAPI) call(op string, httpMethod string, url string, idempotent bool) call {
+	return call{op, httpMethod, url, api.Config, ProfilesApi, idempotent}
 }
 
 // CreateProfile Creates a new profile.
 func (api ProfilesAPI) CreateProfile(profile Profile) (*ProfileResponse, error) {
-	url := api.Config.BaseUrl() + profilesBaseUrl
-	responseType := ProfileResponse{}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.ProfilesApiKey, profile, &responseType)
-	if err != nil {
-		return nil, err
+	return api.CreateProfileContext(context.Background(), profile)
+}
+
+// CreateProfileContext is CreateProfile with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) CreateProfileContext(ctx context.Context, profile Profile) (*ProfileResponse, error) {
+	if api.checkCards && profile.Card.Number != "" {
+		if err := checkCard(api.Config, profile.Card); err != nil {
+			return nil, err
+		}
 	}
-	//fmt.Printf("CreateProfile result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-	return pr, nil
+	url := api.Config.BaseUrl() + profilesBaseUrl
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpCreateProfile, http.MethodPost, url, false), profile)
 }
 
 // GetProfile Retrieves a profile using the profile ID. This ID is returned when you create
 // a profile.
 func (api ProfilesAPI) GetProfile(profileId string) (*Profile, error) {
+	return api.GetProfileContext(context.Background(), profileId)
+}
+
+// GetProfileContext is GetProfile with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) GetProfileContext(ctx context.Context, profileId string) (*Profile, error) {
 	url := api.Config.BaseUrl() + profileUrl
 	url = fmt.Sprintf(url, profileId)
 
-	responseType := Profile{}
-	res, err := Process(http.MethodGet, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &responseType)
+	pr, err := execute[Profile](ctx, api.transport, api.call(OpGetProfile, http.MethodGet, url, true), nil)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Printf("GetProfile result: %T %v\n", res, res)
-	pr := res.(*Profile)
-	pr.ModifiedDate = AsDate(pr.modified, api.Config)
 	pr.Id = profileId
 	return pr, nil
 }
 
 // UpdateProfile Updates a profile
 func (api ProfilesAPI) UpdateProfile(profile *Profile) (*ProfileResponse, error) {
+	return api.UpdateProfileContext(context.Background(), profile)
+}
+
+// UpdateProfileContext is UpdateProfile with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) UpdateProfileContext(ctx context.Context, profile *Profile) (*ProfileResponse, error) {
 	url := api.Config.BaseUrl() + profileUrl
 	url = fmt.Sprintf(url, profile.Id)
 	profile.Card = CreditCard{} // do not update cards here. To modify the cards use UpdateCard
 	profile.Token = Token{}     // can only create a profile with a token, cannot update the token
 
-	responseType := ProfileResponse{}
-	res, err := ProcessBody(http.MethodPut, url, api.Config.MerchantId, api.Config.ProfilesApiKey, profile, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	//fmt.Printf("UpdateProfile result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-	return pr, nil
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpUpdateProfile, http.MethodPut, url, false), profile)
 }
 
 // DeleteProfile Deletes a profile
 func (api ProfilesAPI) DeleteProfile(profileId string) (*ProfileResponse, error) {
+	return api.DeleteProfileContext(context.Background(), profileId)
+}
+
+// DeleteProfileContext is DeleteProfile with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) DeleteProfileContext(ctx context.Context, profileId string) (*ProfileResponse, error) {
 	url := api.Config.BaseUrl() + profileUrl
 	url = fmt.Sprintf(url, profileId)
 
-	responseType := ProfileResponse{}
-	res, err := Process(http.MethodDelete, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	//fmt.Printf("GetProfile result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-	return pr, nil
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpDeleteProfile, http.MethodDelete, url, false), nil)
 }
 
 // GetCards gets all cards on a profile
 func (api ProfilesAPI) GetCards(profileId string) ([]CreditCard, error) {
+	return api.GetCardsContext(context.Background(), profileId)
+}
+
+// GetCardsContext is GetCards with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) GetCardsContext(ctx context.Context, profileId string) ([]CreditCard, error) {
 	url := api.Config.BaseUrl() + cardsBaseUrl
 	url = fmt.Sprintf(url, profileId)
 
-	responseType := profileCardsResponse{}
-	res, err := Process(http.MethodGet, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &responseType)
+	pr, err := execute[profileCardsResponse](ctx, api.transport, api.call(OpGetCards, http.MethodGet, url, true), nil)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Printf("GetCards result: %T %v\n", res, res)
-	pr := res.(*profileCardsResponse)
 	return pr.Cards, nil
 }
 
 // GetCard Gets a single card from a profile. Cards are indexed starting with id 1 (not zero)
 func (api ProfilesAPI) GetCard(profileId string, cardId int) (*CreditCard, error) {
+	return api.GetCardContext(context.Background(), profileId, cardId)
+}
+
+// GetCardContext is GetCard with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) GetCardContext(ctx context.Context, profileId string, cardId int) (*CreditCard, error) {
 	url := api.Config.BaseUrl() + cardsBaseUrl
 	url = fmt.Sprintf(url, profileId)
 
-	responseType := profileCardsResponse{}
-	res, err := Process(http.MethodGet, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &responseType)
+	pr, err := execute[profileCardsResponse](ctx, api.transport, api.call(OpGetCards, http.MethodGet, url, true), nil)
 	if err != nil {
 		return nil, err
 	}
-	//fmt.Printf("GetCard result: %T %v\n", res, res)
-	pr := res.(*profileCardsResponse)
 	if pr.Cards == nil || cardId < 1 || cardId > len(pr.Cards) {
-		return nil, &BeanstreamApiException{400, 0, 0, "cardId not in the range of available cards!", "", nil}
+		return nil, &BeanstreamApiException{400, 0, 0, "cardId not in the range of available cards!", "", nil, nil}
 	}
 
 	return &pr.Cards[cardId-1], nil
@@ -122,58 +134,60 @@ func (api ProfilesAPI) GetCard(profileId string, cardId int) (*CreditCard, error
 
 // AddCard Add a card to a profile
 func (api ProfilesAPI) AddCard(profileId string, card CreditCard) (*ProfileResponse, error) {
+	return api.AddCardContext(context.Background(), profileId, card)
+}
+
+// AddCardContext is AddCard with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) AddCardContext(ctx context.Context, profileId string, card CreditCard) (*ProfileResponse, error) {
+	if api.checkCards {
+		if err := checkCard(api.Config, card); err != nil {
+			return nil, err
+		}
+	}
 	url := api.Config.BaseUrl() + cardsBaseUrl
 	url = fmt.Sprintf(url, profileId)
 
 	wrapper := cardWrapper{card}
-	responseType := ProfileResponse{}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &wrapper, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	fmt.Printf("AddCard result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-
-	return pr, nil
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpAddCard, http.MethodPost, url, false), &wrapper)
 }
 
 // AddTokenizedCard Add a tokenized card to a profile
 func (api ProfilesAPI) AddTokenizedCard(profileId string, cardholderName string, token string) (*ProfileResponse, error) {
+	return api.AddTokenizedCardContext(context.Background(), profileId, cardholderName, token)
+}
+
+// AddTokenizedCardContext is AddTokenizedCard with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) AddTokenizedCardContext(ctx context.Context, profileId string, cardholderName string, token string) (*ProfileResponse, error) {
 	url := api.Config.BaseUrl() + cardsBaseUrl
 	url = fmt.Sprintf(url, profileId)
 
 	t := Token{Token: token, Name: cardholderName}
 	//card := CreditCard{Number: token}
 	wrapper := tokenWrapper{Token: t}
-	responseType := ProfileResponse{}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &wrapper, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	fmt.Printf("AddCard result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-
-	return pr, nil
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpAddCard, http.MethodPost, url, false), &wrapper)
 }
 
 // DeleteCard Deletes a card from a profile
 func (api ProfilesAPI) DeleteCard(profileId string, cardId int) (*ProfileResponse, error) {
+	return api.DeleteCardContext(context.Background(), profileId, cardId)
+}
+
+// DeleteCardContext is DeleteCard with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) DeleteCardContext(ctx context.Context, profileId string, cardId int) (*ProfileResponse, error) {
 	url := api.Config.BaseUrl() + cardUrl
 	url = fmt.Sprintf(url, profileId, cardId)
 
-	responseType := ProfileResponse{}
-	res, err := Process(http.MethodDelete, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	//fmt.Printf("GetCard result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-	return pr, nil
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpDeleteCard, http.MethodDelete, url, false), nil)
 }
 
 // UpdateCard Updates a card stored on a profile. This will NOT update the card number. To update
 // a card number you must remove the old card and add the new one.
 func (api ProfilesAPI) UpdateCard(profileId string, card CreditCard) (*ProfileResponse, error) {
+	return api.UpdateCardContext(context.Background(), profileId, card)
+}
+
+// UpdateCardContext is UpdateCard with a context. Cancelling ctx aborts the request.
+func (api ProfilesAPI) UpdateCardContext(ctx context.Context, profileId string, card CreditCard) (*ProfileResponse, error) {
 	url := api.Config.BaseUrl() + cardUrl
 	url = fmt.Sprintf(url, profileId, card.Id)
 
@@ -182,14 +196,7 @@ func (api ProfilesAPI) UpdateCard(profileId string, card CreditCard) (*ProfileRe
 	}
 
 	wrapper := cardWrapper{card}
-	responseType := ProfileResponse{}
-	res, err := ProcessBody(http.MethodPut, url, api.Config.MerchantId, api.Config.ProfilesApiKey, &wrapper, &responseType)
-	if err != nil {
-		return nil, err
-	}
-	//fmt.Printf("GetCard result: %T %v\n", res, res)
-	pr := res.(*ProfileResponse)
-	return pr, nil
+	return execute[ProfileResponse](ctx, api.transport, api.call(OpUpdateCard, http.MethodPut, url, false), &wrapper)
 }
 
 /*
@@ -211,36 +218,71 @@ type Profile struct {
 	Custom          CustomFields `json:"custom,omitempty"`
 	Language        string       `json:"language,omitempty"`
 	Comment         string       `json:"comment,omitempty"`
-	modified        string       `json:"modified_date,omitempty"`
+	modified        string       // parsed into ModifiedDate
 	LastTransaction string       `json:"last_transaction,omitempty"`
 	Status          string       `json:"status,omitempty"`
 	ModifiedDate    time.Time
+	Response        *ResponseInfo `json:"-"` // set when Gateway.CaptureResponses is on
+	DateErr         error         `json:"-"` // why ModifiedDate was left zero, if it could not be parsed
+}
+
+// afterDecode fills in the parsed modification date.
+func (p *Profile) afterDecode(config Config) {
+	var err error
+	if p.ModifiedDate, err = ParseDate(p.modified, config); err != nil {
+		p.DateErr = fmt.Errorf("profile modified_date: %v", err)
+	}
 }
 
 // GetCards Retrieves all cards from a profile
 func (p *Profile) GetCards(pAPI ProfilesAPI) ([]CreditCard, error) {
-	return pAPI.GetCards(p.Id)
+	return p.GetCardsContext(context.Background(), pAPI)
+}
+
+// GetCardsContext is GetCards with a context. Cancelling ctx aborts the request.
+func (p *Profile) GetCardsContext(ctx context.Context, pAPI ProfilesAPI) ([]CreditCard, error) {
+	return pAPI.GetCardsContext(ctx, p.Id)
 }
 
 // GetCard Get a single card from a profile. Cards are indexed starting with id 1 (not zero)
 func (p *Profile) GetCard(pAPI ProfilesAPI, cardId int) (*CreditCard, error) {
-	return pAPI.GetCard(p.Id, cardId)
+	return p.GetCardContext(context.Background(), pAPI, cardId)
+}
+
+// GetCardContext is GetCard with a context. Cancelling ctx aborts the request.
+func (p *Profile) GetCardContext(ctx context.Context, pAPI ProfilesAPI, cardId int) (*CreditCard, error) {
+	return pAPI.GetCardContext(ctx, p.Id, cardId)
 }
 
 // AddCard Add a card to a profile
 func (p *Profile) AddCard(pAPI ProfilesAPI, card CreditCard) (*ProfileResponse, error) {
-	return pAPI.AddCard(p.Id, card)
+	return p.AddCardContext(context.Background(), pAPI, card)
+}
+
+// AddCardContext is AddCard with a context. Cancelling ctx aborts the request.
+func (p *Profile) AddCardContext(ctx context.Context, pAPI ProfilesAPI, card CreditCard) (*ProfileResponse, error) {
+	return pAPI.AddCardContext(ctx, p.Id, card)
 }
 
 // UpdateCard Updates a card stored on a profile. This will NOT update the card number. To update
 // a card number you must remove the old card and add the new one.
 func (p *Profile) UpdateCard(pAPI ProfilesAPI, card CreditCard) (*ProfileResponse, error) {
-	return pAPI.UpdateCard(p.Id, card)
+	return p.UpdateCardContext(context.Background(), pAPI, card)
+}
+
+// UpdateCardContext is UpdateCard with a context. Cancelling ctx aborts the request.
+func (p *Profile) UpdateCardContext(ctx context.Context, pAPI ProfilesAPI, card CreditCard) (*ProfileResponse, error) {
+	return pAPI.UpdateCardContext(ctx, p.Id, card)
 }
 
 // DeleteCard Deletes a card from a profile
 func (p *Profile) DeleteCard(pAPI ProfilesAPI, cardId int) (*ProfileResponse, error) {
-	return pAPI.DeleteCard(p.Id, cardId)
+	return p.DeleteCardContext(context.Background(), pAPI, cardId)
+}
+
+// DeleteCardContext is DeleteCard with a context. Cancelling ctx aborts the request.
+func (p *Profile) DeleteCardContext(ctx context.Context, pAPI ProfilesAPI, cardId int) (*ProfileResponse, error) {
+	return pAPI.DeleteCardContext(ctx, p.Id, cardId)
 }
 
 // used internally when adding a new card to a profile
@@ -258,6 +300,8 @@ type ProfileResponse struct {
 	Id      string `json:"customer_code,omitempty"`
 	Code    int    `json:"code,omitempty"`
 	Message string `json:"message,omitempty"`
+
+	Response *ResponseInfo `json:"-"` // set when Gateway.CaptureResponses is on
 }
 
 func (p ProfileResponse) String() string {
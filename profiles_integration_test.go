@@ -73,7 +73,7 @@ func TestIntegration_Profiles_MakePayment(t *testing.T) {
 	payment := PaymentRequest{
 		PaymentMethod: paymentMethods.PROFILE,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        12.99,
+		Amount:        MustParseMoney("12.99"),
 		Profile: ProfilePayment{
 			res.Id,
 			1,
@@ -81,8 +81,8 @@ func TestIntegration_Profiles_MakePayment(t *testing.T) {
 	res2, err2 := gateway.Payments().MakePayment(payment)
 	assert.Nil(t, err2)
 	assert.NotNil(t, res2)
-	assert.Equal(t, 1, res2.Approved)
-	assert.Equal(t, "P", res2.Type)
+	assert.Equal(t, StatusApproved, res2.Approved)
+	assert.Equal(t, TransactionPurchase, res2.Type)
 
 	// delete profile
 	profile, err3 := gateway.Profiles().DeleteProfile(res.Id)
@@ -129,7 +129,7 @@ func TestIntegration_Profiles_CreateProfileFromToken(t *testing.T) {
 	payment := PaymentRequest{
 		PaymentMethod: paymentMethods.PROFILE,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        14.99,
+		Amount:        MustParseMoney("14.99"),
 		Profile: ProfilePayment{
 			res.Id,
 			1,
@@ -138,18 +138,18 @@ func TestIntegration_Profiles_CreateProfileFromToken(t *testing.T) {
 	res2, err2 := gateway.Payments().MakePayment(payment)
 	assert.Nil(t, err2)
 	assert.NotNil(t, res2)
-	assert.Equal(t, 1, res2.Approved)
-	assert.Equal(t, "P", res2.Type)
+	assert.Equal(t, StatusApproved, res2.Approved)
+	assert.Equal(t, TransactionPurchase, res2.Type)
 
 	// step 4: Make another payment
 	payment.OrderNumber = Util_randOrderId(6)
-	payment.Amount = 1.89
+	payment.Amount = MustParseMoney("1.89")
 	payment.Comment = "A 2nd payment with the same token profile"
 	res3, err3 := gateway.Payments().MakePayment(payment)
 	assert.Nil(t, err3)
 	assert.NotNil(t, res3)
-	assert.Equal(t, 1, res3.Approved)
-	assert.Equal(t, "P", res3.Type)
+	assert.Equal(t, StatusApproved, res3.Approved)
+	assert.Equal(t, TransactionPurchase, res3.Type)
 
 	// clean up: delete profile
 	profile, err4 := gateway.Profiles().DeleteProfile(res.Id)
@@ -560,7 +560,7 @@ func TestIntegration_Profiles_AddTokenizedCard(t *testing.T) {
 	payment := PaymentRequest{
 		PaymentMethod: paymentMethods.PROFILE,
 		OrderNumber:   Util_randOrderId(6),
-		Amount:        4.49,
+		Amount:        MustParseMoney("4.49"),
 		Profile: ProfilePayment{
 			res.Id,
 			2, // use 2nd tokenized card
@@ -0,0 +1,200 @@
+package beanstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+/*
+RedirectException is returned by MakePayment when the payment cannot finish
+until the customer has been sent elsewhere, such as to their card issuer for
+3-D Secure authentication or to their bank for Interac Online. Show them
+Contents, an HTML form that submits itself, or build your own redirect from
+FormAction and FormFields:
+	res, err := gateway.Payments().MakePayment(request)
+	var redirect *beanstream.RedirectException
+	if errors.As(err, &redirect) {
+		io.WriteString(w, redirect.Contents)
+		return
+	}
+The issuer then posts the customer back to the request's TermUrl. Pass what it
+posted to ContinueCardPayment, or MerchantData and the result to
+ContinuePayment, to complete the payment. For Interac Online, keep MerchantData
+(in the customer's session, say) and call ContinueInteracPayment when the bank
+sends them back.
+*/
+type RedirectException struct {
+	MerchantData string        // identifies the payment to ContinuePayment
+	Contents     string        // the HTML page to show the customer
+	FormAction   string        // where the form in Contents posts to
+	FormFields   url.Values    // the form's hidden fields, such as PaReq, MD and TermUrl
+	Response     *ResponseInfo // set when Gateway.CaptureResponses is on
+}
+
+func (e *RedirectException) Error() string {
+	return fmt.Sprintf("payment requires a redirect to %v (merchant data %v)", e.FormAction, e.MerchantData)
+}
+
+// JSON:
+//
+//	{
+//		"merchant_data":"ba1d5f5a-d8e5-4f9b-bc49-3a2b4e2f9ab1",
+//		"contents":"%3cHTML%3e%3cHEAD%3e...%3c%2fHTML%3e"
+//	}
+type redirectResponse struct {
+	MerchantData string `json:"merchant_data"`
+	Contents     string `json:"contents"`
+}
+
+// merchantDataPattern is what MerchantData looks like: the gateway issues
+// identifiers such as "ba1d5f5a-d8e5-4f9b-bc49-3a2b4e2f9ab1".
+var merchantDataPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+var (
+	formActionPattern = regexp.MustCompile(`(?is)<form[^>]*\saction\s*=\s*["']([^"']*)["']`)
+	inputPattern      = regexp.MustCompile(`(?is)<input[^>]*>`)
+	nameAttrPattern   = regexp.MustCompile(`(?is)\sname\s*=\s*["']([^"']*)["']`)
+	valueAttrPattern  = regexp.MustCompile(`(?is)\svalue\s*=\s*["']([^"']*)["']`)
+)
+
+// parseRedirect reads the body of a 302 from the gateway. It returns nil if the
+// body is not a redirect, leaving it to be reported as an error.
+func parseRedirect(body []byte) *RedirectException {
+	var r redirectResponse
+	if err := json.Unmarshal(body, &r); err != nil || r.MerchantData == "" {
+		return nil
+	}
+	contents, err := url.QueryUnescape(r.Contents)
+	if err != nil {
+		contents = r.Contents // already decoded
+	}
+	e := &RedirectException{MerchantData: r.MerchantData, Contents: contents, FormFields: url.Values{}}
+	if m := formActionPattern.FindStringSubmatch(contents); m != nil {
+		e.FormAction = html.UnescapeString(m[1])
+	}
+	for _, input := range inputPattern.FindAllString(contents, -1) {
+		name := nameAttrPattern.FindStringSubmatch(input)
+		if name == nil {
+			continue
+		}
+		value := ""
+		if m := valueAttrPattern.FindStringSubmatch(input); m != nil {
+			value = html.UnescapeString(m[1])
+		}
+		e.FormFields.Add(html.UnescapeString(name[1]), value)
+	}
+	return e
+}
+
+// ContinueRequest finishes a payment that was interrupted by a RedirectException.
+type ContinueRequest struct {
+	PaymentMethod   string           `json:"payment_method"` // "credit_card" for 3-D Secure, or "interac"
+	CardResponse    *CardResponse    `json:"card_response,omitempty"`
+	InteracResponse *InteracResponse `json:"interac_response,omitempty"`
+}
+
+// CardResponse is the result of 3-D Secure authentication, as posted to TermUrl.
+type CardResponse struct {
+	PaRes string `json:"pa_res"`
+}
+
+/*
+ContinuePayment completes a payment after the customer has returned from a
+redirect. merchantData is RedirectException.MerchantData, which the issuer
+also posts back to TermUrl as MD. Since it comes back through the customer's
+browser, anything but a plain identifier is refused without being sent.
+*/
+func (api PaymentsAPI) ContinuePayment(merchantData string, request ContinueRequest) (*PaymentResponse, error) {
+	return api.ContinuePaymentContext(context.Background(), merchantData, request)
+}
+
+// ContinuePaymentContext is ContinuePayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) ContinuePaymentContext(ctx context.Context, merchantData string, request ContinueRequest) (*PaymentResponse, error) {
+	if !merchantDataPattern.MatchString(merchantData) {
+		return nil, &BeanstreamApiException{400, 0, 0, fmt.Sprintf("invalid merchant data %q", merchantData), "", nil, nil}
+	}
+	endpoint := fmt.Sprintf(api.Config.BaseUrl()+continueUrl, url.PathEscape(merchantData))
+	return execute[PaymentResponse](ctx, api.transport, api.call(OpContinuePayment, http.MethodPost, endpoint, false), request)
+}
+
+/*
+ContinueCardPayment completes a 3-D Secure card payment from the form the
+issuer posted to TermUrl, which carries MD and PaRes:
+	func termUrlHandler(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		res, err := gateway.Payments().ContinueCardPayment(r.PostForm)
+		...
+	}
+*/
+func (api PaymentsAPI) ContinueCardPayment(form url.Values) (*PaymentResponse, error) {
+	return api.ContinueCardPaymentContext(context.Background(), form)
+}
+
+// ContinueCardPaymentContext is ContinueCardPayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) ContinueCardPaymentContext(ctx context.Context, form url.Values) (*PaymentResponse, error) {
+	merchantData, paRes := form.Get("MD"), form.Get("PaRes")
+	if merchantData == "" || paRes == "" {
+		return nil, &BeanstreamApiException{400, 0, 0, "the form posted to TermUrl must have MD and PaRes", "", nil, nil}
+	}
+	request := ContinueRequest{PaymentMethod: "credit_card", CardResponse: &CardResponse{paRes}}
+	return api.ContinuePaymentContext(ctx, merchantData, request)
+}
+
+/*
+InteracResponse is what the customer's bank sends back with them after an
+Interac Online payment, as the IDEBIT_ fields of the request to your funded
+or non-funded URL.
+*/
+type InteracResponse struct {
+	Funded        string `json:"funded"` // 1 if the customer paid, 0 if not
+	IdebitTrack2  string `json:"idebit_track2"`
+	IdebitIssLang string `json:"idebit_isslang"`
+	IdebitVersion string `json:"idebit_version"`
+	IdebitIssConf string `json:"idebit_issconf"`
+	IdebitIssName string `json:"idebit_issname"`
+	IdebitAmount  string `json:"idebit_amount"`
+	IdebitInvoice string `json:"idebit_invoice"`
+}
+
+/*
+ContinueInteracPayment completes an Interac Online payment from the request
+the customer's bank sent them back with. merchantData is the MerchantData of
+the RedirectException from MakePayment, and funded tells which of your two
+return URLs was hit:
+	func fundedHandler(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		res, err := gateway.Payments().ContinueInteracPayment(session.MerchantData, true, r.Form)
+		...
+	}
+A non-funded payment still has to be continued; the gateway then declines it.
+*/
+func (api PaymentsAPI) ContinueInteracPayment(merchantData string, funded bool, form url.Values) (*PaymentResponse, error) {
+	return api.ContinueInteracPaymentContext(context.Background(), merchantData, funded, form)
+}
+
+// ContinueInteracPaymentContext is ContinueInteracPayment with a context. Cancelling ctx aborts the request.
+func (api PaymentsAPI) ContinueInteracPaymentContext(ctx context.Context, merchantData string, funded bool, form url.Values) (*PaymentResponse, error) {
+	if merchantData == "" {
+		return nil, &BeanstreamApiException{400, 0, 0, "the merchant data of the Interac redirect is missing", "", nil, nil}
+	}
+	res := &InteracResponse{
+		Funded:        "0",
+		IdebitTrack2:  form.Get("IDEBIT_TRACK2"),
+		IdebitIssLang: form.Get("IDEBIT_ISSLANG"),
+		IdebitVersion: form.Get("IDEBIT_VERSION"),
+		IdebitIssConf: form.Get("IDEBIT_ISSCONF"),
+		IdebitIssName: form.Get("IDEBIT_ISSNAME"),
+		IdebitAmount:  form.Get("IDEBIT_AMOUNT"),
+		IdebitInvoice: form.Get("IDEBIT_INVOICE")}
+	if funded {
+		res.Funded = "1"
+	}
+	request := ContinueRequest{PaymentMethod: paymentMethods.INTERAC, InteracResponse: res}
+	return api.ContinuePaymentContext(ctx, merchantData, request)
+}
@@ -0,0 +1,108 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const redirectForm = `<HTML><HEAD></HEAD><BODY><FORM action="https://acs.example.com/pareq?a=1&amp;b=2" method="POST" id="frm">` +
+	`<input type="hidden" name="PaReq" value="eJxVUt1ugjAU"><input type="hidden" name="MD" value="A1B2C3">` +
+	`<input type="hidden" name="TermUrl" value="https://shop.example.com/3ds"></FORM></BODY></HTML>`
+
+func TestUnit_Redirect_Parse(t *testing.T) {
+	redirect := parseRedirect(redirectBody)
+	assert.NotNil(t, redirect)
+	assert.Equal(t, "A1B2C3", redirect.MerchantData)
+	assert.Equal(t, redirectForm, redirect.Contents)
+	assert.Equal(t, "https://acs.example.com/pareq?a=1&b=2", redirect.FormAction)
+	assert.Equal(t, "eJxVUt1ugjAU", redirect.FormFields.Get("PaReq"))
+	assert.Equal(t, "A1B2C3", redirect.FormFields.Get("MD"))
+	assert.Equal(t, "https://shop.example.com/3ds", redirect.FormFields.Get("TermUrl"))
+
+	assert.Nil(t, parseRedirect([]byte(`{"code":1,"message":"declined"}`)))
+	assert.Nil(t, parseRedirect([]byte(`<html/>`)))
+}
+
+// redirectBody is what the gateway sends with a 302 for the redirectForm.
+var redirectBody, _ = json.Marshal(redirectResponse{"A1B2C3", url.QueryEscape(redirectForm)})
+
+func TestUnit_Redirect_ContinueCardPayment(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/continue", 200, `{"id":"10000001","approved":"1","message":"Approved"}`).
+		on(http.MethodPost, "/payments", 302, string(redirectBody))
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{TermUrl: "https://shop.example.com/3ds"})
+	var redirect *RedirectException
+	assert.True(t, errors.As(err, &redirect))
+	assert.Equal(t, "A1B2C3", redirect.MerchantData)
+
+	res, err := gateway.Payments().ContinueCardPayment(url.Values{"MD": {"A1B2C3"}, "PaRes": {"eJzVWNmyqkgW"}})
+	assert.Nil(t, err)
+	assert.True(t, res.IsApproved())
+	assert.Equal(t, "/api/v1/payments/A1B2C3/continue", fake.received()[1].Path)
+	assert.JSONEq(t, `{"payment_method":"credit_card","card_response":{"pa_res":"eJzVWNmyqkgW"}}`, fake.received()[1].Body)
+
+	_, err = gateway.Payments().ContinueCardPayment(url.Values{"MD": {"A1B2C3"}})
+	assert.Equal(t, 400, err.(*BeanstreamApiException).Status)
+	assert.Equal(t, 2, len(fake.received()))
+}
+
+func TestUnit_Redirect_HostileMerchantDataRefused(t *testing.T) {
+	fake := &fakeGateway{}
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	for _, md := range []string{"10000001/void?x=", "../../profiles", "A1B2C3%2Fvoid", "A1B2C3#frag", "A1 B2"} {
+		_, err := gateway.Payments().ContinueCardPayment(url.Values{"MD": {md}, "PaRes": {"eJzVWNmyqkgW"}})
+		apiErr, ok := err.(*BeanstreamApiException)
+		assert.True(t, ok, md)
+		assert.Equal(t, 400, apiErr.Status, md)
+	}
+	_, err := gateway.Payments().ContinueInteracPayment("10000001/void?x=", true, url.Values{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(fake.received()))
+}
+
+func TestUnit_Redirect_ContinueInteracPayment(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/continue", 200, `{"id":"10000001","approved":"1","message":"Approved"}`).
+		on(http.MethodPost, "/payments", 302, string(redirectBody))
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.INTERAC, Amount: MustParseMoney("30.00")})
+	var redirect *RedirectException
+	assert.True(t, errors.As(err, &redirect))
+	assert.True(t, strings.Contains(fake.received()[0].Body, `"payment_method":"interac"`))
+
+	form := url.Values{"IDEBIT_TRACK2": {"3728024906540591206=01121122334455000"}, "IDEBIT_ISSLANG": {"en"}, "IDEBIT_VERSION": {"1"},
+		"IDEBIT_ISSCONF": {"CONF#TEST"}, "IDEBIT_ISSNAME": {"TestBank1"}, "IDEBIT_AMOUNT": {"3000"}, "IDEBIT_INVOICE": {"INV1"}}
+	res, err := gateway.Payments().ContinueInteracPayment(redirect.MerchantData, true, form)
+	assert.Nil(t, err)
+	assert.True(t, res.IsApproved())
+	assert.Equal(t, "/api/v1/payments/A1B2C3/continue", fake.received()[1].Path)
+	assert.JSONEq(t, `{"payment_method":"interac","interac_response":{"funded":"1","idebit_track2":"3728024906540591206=01121122334455000",
+		"idebit_isslang":"en","idebit_version":"1","idebit_issconf":"CONF#TEST","idebit_issname":"TestBank1","idebit_amount":"3000","idebit_invoice":"INV1"}}`, fake.received()[1].Body)
+
+	_, err = gateway.Payments().ContinueInteracPayment("", true, form)
+	assert.Equal(t, 400, err.(*BeanstreamApiException).Status)
+}
+
+func TestUnit_Redirect_LocationNotFollowed(t *testing.T) {
+	fake := (&fakeGateway{Header: http.Header{"Location": {"https://acs.example.com/pareq"}}}).
+		on(http.MethodPost, "/payments", 302, string(redirectBody))
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{TermUrl: "https://shop.example.com/3ds"})
+	var redirect *RedirectException
+	assert.True(t, errors.As(err, &redirect), "Error is not a RedirectException: %v", err)
+	assert.Equal(t, []string{"POST /api/v1/payments"}, fake.calls())
+	assert.NotNil(t, defaultHTTPClient.CheckRedirect)
+}
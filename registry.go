@@ -0,0 +1,271 @@
+package beanstream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+Registry holds the Configs of several merchant accounts, for example one per
+country or business unit, each under a key of your choosing. Payments are
+routed to a merchant by key or by Route rules, and QueryAll searches every
+merchant at once.
+	registry := beanstream.NewRegistry()
+	registry.Add("ca", caConfig)
+	registry.Add("us", usConfig)
+	registry.AddRoute(beanstream.RouteByRef(1, map[string]string{"CA": "ca", "US": "us"}))
+	key, res, err := registry.MakePayment(request)
+	...
+	gateway, _ := registry.Gateway(key)
+	gateway.Payments().CompletePayment(res.ID, completion)
+
+The Gateway settings, HTTPClient through CheckCards, are shared by the
+gateways of all merchants, so every merchant uses the same pool of
+connections. Set them before making calls. A Registry is safe for concurrent
+use.
+*/
+type Registry struct {
+	HTTPClient       *http.Client
+	Retry            RetryPolicy
+	Logger           Logger
+	Interceptors     []Interceptor
+	CaptureResponses bool
+	CheckReturns     bool
+	Verification     VerificationPolicy
+	CheckCards       bool
+
+	mu      sync.RWMutex
+	keys    []string
+	configs map[string]Config
+	routes  []Route
+	def     string
+}
+
+// Route picks the merchant for a payment. It returns false when the rule does
+// not apply, and the next rule is tried.
+type Route func(request PaymentRequest) (key string, ok bool)
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{configs: make(map[string]Config)}
+}
+
+// Add registers the config of a merchant under key, replacing any config
+// already registered under it. The first merchant added is the default one,
+// used when no Route applies.
+func (r *Registry) Add(key string, config Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.configs[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.configs[key] = config
+	if r.def == "" {
+		r.def = key
+	}
+}
+
+// SetDefault sets the merchant used when no Route applies. An empty key
+// means a payment that no Route matches is rejected.
+func (r *Registry) SetDefault(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.def = key
+}
+
+// AddRoute appends a routing rule. Rules are tried in the order they were added.
+func (r *Registry) AddRoute(route Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route)
+}
+
+// Keys returns the keys of the registered merchants in the order they were added.
+func (r *Registry) Keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.keys...)
+}
+
+// Gateway returns a Gateway for the merchant registered under key.
+func (r *Registry) Gateway(key string) (*Gateway, error) {
+	r.mu.RLock()
+	config, ok := r.configs[key]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("beanstream: no merchant registered as %q", key)
+	}
+	return &Gateway{
+		Config:           config,
+		HTTPClient:       r.HTTPClient,
+		Retry:            r.Retry,
+		Logger:           r.Logger,
+		Interceptors:     r.Interceptors,
+		CaptureResponses: r.CaptureResponses,
+		CheckReturns:     r.CheckReturns,
+		Verification:     r.Verification,
+		CheckCards:       r.CheckCards}, nil
+}
+
+// Route returns the key of the merchant that should take the payment: the
+// first Route that applies, otherwise the default merchant.
+func (r *Registry) Route(request PaymentRequest) (string, error) {
+	r.mu.RLock()
+	routes, def := r.routes, r.def
+	r.mu.RUnlock()
+	for _, route := range routes {
+		if key, ok := route(request); ok {
+			return key, nil
+		}
+	}
+	if def == "" {
+		return "", fmt.Errorf("beanstream: no merchant for order %q", request.OrderNumber)
+	}
+	return def, nil
+}
+
+/*
+MakePayment routes the payment to a merchant and makes it there. The returned
+key identifies that merchant; use it with Gateway to complete, void or return
+the payment later.
+*/
+func (r *Registry) MakePayment(request PaymentRequest) (string, *PaymentResponse, error) {
+	return r.MakePaymentContext(context.Background(), request)
+}
+
+// MakePaymentContext is MakePayment with a context. Cancelling ctx aborts the request.
+func (r *Registry) MakePaymentContext(ctx context.Context, request PaymentRequest) (string, *PaymentResponse, error) {
+	key, err := r.Route(request)
+	if err != nil {
+		return "", nil, err
+	}
+	gateway, err := r.Gateway(key)
+	if err != nil {
+		return key, nil, err
+	}
+	res, err := gateway.Payments().MakePaymentContext(ctx, request)
+	return key, res, err
+}
+
+// RouteByRef routes on one of the custom fields Ref1 to Ref5 of the payment,
+// looking its value up in keys.
+func RouteByRef(ref int, keys map[string]string) Route {
+	return func(request PaymentRequest) (string, bool) {
+		var value string
+		switch ref {
+		case 1:
+			value = request.Custom.Ref1
+		case 2:
+			value = request.Custom.Ref2
+		case 3:
+			value = request.Custom.Ref3
+		case 4:
+			value = request.Custom.Ref4
+		case 5:
+			value = request.Custom.Ref5
+		}
+		key, ok := keys[value]
+		return key, ok
+	}
+}
+
+// RouteByCurrency routes a payment to the first merchant, in the order they were
+// added, whose Config settles in the payment's Currency.
+func (r *Registry) RouteByCurrency() Route {
+	return func(request PaymentRequest) (string, bool) {
+		if request.Currency == "" {
+			return "", false
+		}
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		for _, key := range r.keys {
+			if r.configs[key].Currency == request.Currency {
+				return key, true
+			}
+		}
+		return "", false
+	}
+}
+
+// A MerchantRecord is a TransactionRecord found by QueryAll, with the key of
+// the merchant it belongs to.
+type MerchantRecord struct {
+	Merchant string
+	TransactionRecord
+}
+
+// MerchantErrors holds the error of each merchant whose query failed.
+type MerchantErrors map[string]error
+
+func (e MerchantErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, len(keys))
+	for i, key := range keys {
+		msgs[i] = key + ": " + e[key].Error()
+	}
+	return "beanstream: query failed for " + strings.Join(msgs, "; ")
+}
+
+/*
+QueryAll runs ReportsAPI.Query against every merchant at the same time and
+merges the results, oldest first. Records keep the Currency of their
+merchant; sum them with CurrencyTotals.Add rather than adding amounts
+directly. The paging rows apply to each merchant separately, so up to
+(endRow-startRow) records come back per merchant.
+
+If some merchants fail, the records of the others are still returned along
+with a MerchantErrors.
+*/
+func (r *Registry) QueryAll(startTime time.Time, endTime time.Time, startRow int, endRow int, criteria ...Criteria) ([]MerchantRecord, error) {
+	return r.QueryAllContext(context.Background(), startTime, endTime, startRow, endRow, criteria...)
+}
+
+// QueryAllContext is QueryAll with a context. Cancelling ctx aborts the requests.
+func (r *Registry) QueryAllContext(ctx context.Context, startTime time.Time, endTime time.Time, startRow int, endRow int, criteria ...Criteria) ([]MerchantRecord, error) {
+	keys := r.Keys()
+	results := make([][]TransactionRecord, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		gateway, err := r.Gateway(key)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func(i int, gateway *Gateway) {
+			defer wg.Done()
+			results[i], errs[i] = gateway.Reports().QueryContext(ctx, startTime, endTime, startRow, endRow, criteria...)
+		}(i, gateway)
+	}
+	wg.Wait()
+
+	var records []MerchantRecord
+	failed := MerchantErrors{}
+	for i, key := range keys {
+		if errs[i] != nil {
+			failed[key] = errs[i]
+			continue
+		}
+		for _, rec := range results[i] {
+			records = append(records, MerchantRecord{key, rec})
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].DateTime.Before(records[j].DateTime)
+	})
+	if len(failed) > 0 {
+		return records, failed
+	}
+	return records, nil
+}
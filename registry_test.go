@@ -0,0 +1,85 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testRegistry(rt http.RoundTripper) *Registry {
+	registry := NewRegistry()
+	registry.HTTPClient = &http.Client{Transport: rt}
+	registry.Add("ca", Config{MerchantId: "100", PaymentsApiKey: "a", ReportingApiKey: "a", TimezoneOffset: "-8:00"})
+	registry.Add("us", Config{MerchantId: "200", PaymentsApiKey: "b", ReportingApiKey: "b", TimezoneOffset: "-8:00"})
+	return registry
+}
+
+func TestUnit_Registry_Route(t *testing.T) {
+	fake := (&fakeGateway{}).on(http.MethodPost, "/payments", 200, `{"id":"2"}`).on(http.MethodPost, "/payments", 200, `{"id":"1"}`)
+	registry := testRegistry(fake)
+	registry.AddRoute(RouteByRef(2, map[string]string{"US": "us"}))
+
+	key, res, err := registry.MakePayment(PaymentRequest{Custom: CustomFields{Ref2: "US"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "us", key)
+	assert.Equal(t, "2", res.ID)
+	assert.Equal(t, "200", fake.received()[0].Merchant())
+
+	// no rule applies, so the first merchant added takes it
+	key, res, err = registry.MakePayment(PaymentRequest{Custom: CustomFields{Ref2: "MX"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "ca", key)
+	assert.Equal(t, "100", fake.received()[1].Merchant())
+
+	registry.SetDefault("")
+	_, _, err = registry.MakePayment(PaymentRequest{OrderNumber: "X1"})
+	assert.NotNil(t, err)
+
+	registry.AddRoute(func(request PaymentRequest) (string, bool) { return "mx", true })
+	_, _, err = registry.MakePayment(PaymentRequest{})
+	assert.NotNil(t, err)
+}
+
+func TestUnit_Registry_SharedClient(t *testing.T) {
+	registry := testRegistry(&fakeGateway{})
+	ca, _ := registry.Gateway("ca")
+	us, _ := registry.Gateway("us")
+	assert.True(t, ca.Payments().transport.httpClient() == us.Payments().transport.httpClient())
+	assert.Equal(t, []string{"ca", "us"}, registry.Keys())
+}
+
+func TestUnit_Registry_QueryAll(t *testing.T) {
+	registry := testRegistry((&fakeGateway{}).handle(http.MethodPost, "/reports", func(req fakeRequest) (int, string) {
+		if req.Merchant() == "100" {
+			return 200, `{"records":[{"trn_id":11},{"trn_id":12}]}`
+		}
+		return 200, `{"records":[{"trn_id":21}]}`
+	}))
+
+	records, err := registry.QueryAll(time.Now().Add(-time.Hour), time.Now(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(records))
+	assert.Equal(t, "ca", records[0].Merchant)
+	assert.Equal(t, 11, records[0].TransactionId)
+	assert.Equal(t, "us", records[2].Merchant)
+	assert.Equal(t, 21, records[2].TransactionId)
+}
+
+func TestUnit_Registry_QueryAllPartialFailure(t *testing.T) {
+	registry := testRegistry((&fakeGateway{}).handle(http.MethodPost, "/reports", func(req fakeRequest) (int, string) {
+		if req.Merchant() == "100" {
+			return 200, `{"records":[{"trn_id":11}]}`
+		}
+		return 500, `{"code":1,"category":2,"message":"Internal error"}`
+	}))
+
+	records, err := registry.QueryAll(time.Now().Add(-time.Hour), time.Now(), 1, 10)
+	assert.Equal(t, 1, len(records))
+	failed, ok := err.(MerchantErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(failed))
+	assert.NotNil(t, failed["us"])
+}
@@ -1,7 +1,8 @@
 package beanstream
 
 import (
-	//"fmt"
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,7 +15,13 @@ The Reports API lets you search for transactions based on date ranges
 and search criteria.
 */
 type ReportsAPI struct {
-	Config Config
+	Config    Config
+	transport transport
+}
+
+// call describes a request made with the Reporting API passcode.
+func (api ReportsAPI) call(op string, httpMethod string, url string, idempotent bool) call {
+	return call{op, httpMethod, url, api.Config, ReportingApi, idempotent}
 }
 
 /*
@@ -30,8 +37,16 @@ For paging just one row, use the values: 1, 2.
 Paging index starts inclusively at the first number and non-inclusively at the 2nd number:
 [start,end).
 The lowest paging index number is 1.
+
+Each record is labelled with the Config's Currency. Use TotalsByCurrency to sum
+records without mixing currencies.
 */
 func (api ReportsAPI) Query(startTime time.Time, endTime time.Time, startRow int, endRow int, criteria ...Criteria) ([]TransactionRecord, error) {
+	return api.QueryContext(context.Background(), startTime, endTime, startRow, endRow, criteria...)
+}
+
+// QueryContext is Query with a context. Cancelling ctx aborts the request.
+func (api ReportsAPI) QueryContext(ctx context.Context, startTime time.Time, endTime time.Time, startRow int, endRow int, criteria ...Criteria) ([]TransactionRecord, error) {
 	url := api.Config.BaseUrl() + reportsBaseUrl
 
 	q := query{
@@ -41,16 +56,10 @@ func (api ReportsAPI) Query(startTime time.Time, endTime time.Time, startRow int
 		strconv.Itoa(startRow),
 		strconv.Itoa(endRow),
 		criteria}
-	responseType := RecordsResult{}
-	res, err := ProcessBody(http.MethodPost, url, api.Config.MerchantId, api.Config.ReportingApiKey, &q, &responseType)
+	pr, err := execute[RecordsResult](ctx, api.transport, api.call(OpQuery, http.MethodPost, url, true), &q)
 	if err != nil {
 		return nil, err
 	}
-
-	pr := res.(*RecordsResult)
-	for _, r := range pr.Records {
-		r.DateTime = AsDate(r.dateTime, api.Config)
-	}
 	return pr.Records, nil
 }
 
@@ -86,57 +95,71 @@ type RecordsResult struct {
 	Records []TransactionRecord `json:"records,omitempty"`
 }
 
+// afterDecode fills in the parsed date and the currency of each record.
+func (r *RecordsResult) afterDecode(config Config) {
+	for i := range r.Records {
+		rec := &r.Records[i]
+		rec.Currency = config.Currency
+		var err error
+		if rec.DateTime, err = ParseDate(rec.dateTime, config); err != nil {
+			rec.DateErr = fmt.Errorf("transaction %v trn_date_time: %v", rec.TransactionId, err)
+		}
+	}
+}
+
 // The transaction in a query RecordsResult
 type TransactionRecord struct {
 	RowId            int    `json:"row_id,omitempty"`
 	TransactionId    int    `json:"trn_id,omitempty"`
-	dateTime         string `json:"trn_date_time,omitempty"`
+	dateTime         string // parsed into DateTime
 	DateTime         time.Time
-	Type             string  `json:"trn_type,omitempty"`
-	OrderNumber      string  `json:"trn_order_number,omitempty"`
-	PaymentMethod    string  `json:"trn_payment_method,omitempty"`
-	Comments         string  `json:"trn_comments,omitempty"`
-	MaskedCard       string  `json:"trn_masked_card,omitempty"`
-	Amount           float32 `json:"trn_amount,string,omitempty"`
-	Returns          float32 `json:"trn_returns,string,omitempty"`
-	Completions      float32 `json:"trn_completions,string,omitempty"`
-	Voided           int     `json:"trn_voided,omitempty"`
-	Response         int     `json:"trn_response,omitempty"`
-	CardType         string  `json:"trn_card_type,omitempty"`
-	BatchNumber      int     `json:"trn_batch_no,omitempty"`
-	AvsResult        string  `json:"trn_avs_result,omitempty"`
-	CvdResult        int     `json:"trn_cvd_result,omitempty"`
-	CardExpiry       string  `json:"trn_card_expiry,omitempty"`
-	MessageId        int     `json:"message_id,omitempty"`
-	MessageText      string  `json:"message_text,omitempty"`
-	CardOwner        string  `json:"trn_card_owner,omitempty"`
-	IpAddress        string  `json:"trn_ip,omitempty"`
-	ApprovalCode     string  `json:"trn_approval_code,omitempty"`
-	Reference        int     `json:"trn_reference,omitempty"`
-	BillingName      string  `json:"b_name,omitempty"`
-	BillingEmail     string  `json:"b_email,omitempty"`
-	BillingPhone     string  `json:"b_phone,omitempty"`
-	BillingAddress1  string  `json:"b_address1,omitempty"`
-	BillingAddress2  string  `json:"b_address2,omitempty"`
-	BillingCity      string  `json:"b_city,omitempty"`
-	BillingProvince  string  `json:"b_province,omitempty"`
-	BillingPostal    string  `json:"b_postal,omitempty"`
-	BillingCountry   string  `json:"b_country,omitempty"`
-	ShippingName     string  `json:"s_name,omitempty"`
-	ShippingEmail    string  `json:"s_email,omitempty"`
-	ShippingPhone    string  `json:"s_phone,omitempty"`
-	ShippingAddress1 string  `json:"s_address1,omitempty"`
-	ShippingAddress2 string  `json:"s_address2,omitempty"`
-	ShippingCity     string  `json:"s_city,omitempty"`
-	ShippingProvince string  `json:"s_province,omitempty"`
-	ShippingPostal   string  `json:"s_postal,omitempty"`
-	ShippingCountry  string  `json:"s_country,omitempty"`
-	Ref1             string  `json:"ref1,omitempty"`
-	Ref2             string  `json:"ref2,omitempty"`
-	Ref3             string  `json:"ref3,omitempty"`
-	Ref4             string  `json:"ref4,omitempty"`
-	Ref5             string  `json:"ref5,omitempty"`
-	ProductName      string  `json:"product_name,omitempty"`
-	ProductId        string  `json:"product_id,omitempty"`
-	CustomerCode     string  `json:"customer_code,omitempty"`
+	Type             TransactionType `json:"trn_type,omitempty"`
+	OrderNumber      string          `json:"trn_order_number,omitempty"`
+	PaymentMethod    string          `json:"trn_payment_method,omitempty"`
+	Comments         string          `json:"trn_comments,omitempty"`
+	MaskedCard       string          `json:"trn_masked_card,omitempty"`
+	Amount           Money           `json:"trn_amount,omitempty"`
+	Returns          Money           `json:"trn_returns,omitempty"`
+	Completions      Money           `json:"trn_completions,omitempty"`
+	Voided           int             `json:"trn_voided,omitempty"`
+	Response         ApprovalStatus  `json:"trn_response,omitempty"`
+	CardType         string          `json:"trn_card_type,omitempty"`
+	BatchNumber      int             `json:"trn_batch_no,omitempty"`
+	AvsResult        AvsResult       `json:"trn_avs_result,omitempty"`
+	CvdResult        CvdResult       `json:"trn_cvd_result,omitempty"`
+	CardExpiry       string          `json:"trn_card_expiry,omitempty"`
+	MessageId        MessageId       `json:"message_id,omitempty"`
+	MessageText      string          `json:"message_text,omitempty"`
+	CardOwner        string          `json:"trn_card_owner,omitempty"`
+	IpAddress        string          `json:"trn_ip,omitempty"`
+	ApprovalCode     string          `json:"trn_approval_code,omitempty"`
+	Reference        int             `json:"trn_reference,omitempty"`
+	BillingName      string          `json:"b_name,omitempty"`
+	BillingEmail     string          `json:"b_email,omitempty"`
+	BillingPhone     string          `json:"b_phone,omitempty"`
+	BillingAddress1  string          `json:"b_address1,omitempty"`
+	BillingAddress2  string          `json:"b_address2,omitempty"`
+	BillingCity      string          `json:"b_city,omitempty"`
+	BillingProvince  string          `json:"b_province,omitempty"`
+	BillingPostal    string          `json:"b_postal,omitempty"`
+	BillingCountry   string          `json:"b_country,omitempty"`
+	ShippingName     string          `json:"s_name,omitempty"`
+	ShippingEmail    string          `json:"s_email,omitempty"`
+	ShippingPhone    string          `json:"s_phone,omitempty"`
+	ShippingAddress1 string          `json:"s_address1,omitempty"`
+	ShippingAddress2 string          `json:"s_address2,omitempty"`
+	ShippingCity     string          `json:"s_city,omitempty"`
+	ShippingProvince string          `json:"s_province,omitempty"`
+	ShippingPostal   string          `json:"s_postal,omitempty"`
+	ShippingCountry  string          `json:"s_country,omitempty"`
+	Ref1             string          `json:"ref1,omitempty"`
+	Ref2             string          `json:"ref2,omitempty"`
+	Ref3             string          `json:"ref3,omitempty"`
+	Ref4             string          `json:"ref4,omitempty"`
+	Ref5             string          `json:"ref5,omitempty"`
+	ProductName      string          `json:"product_name,omitempty"`
+	ProductId        string          `json:"product_id,omitempty"`
+	CustomerCode     string          `json:"customer_code,omitempty"`
+	Currency         Currency        `json:"-"` // the Config's settlement currency
+	DateErr          error           `json:"-"` // why DateTime was left zero, if it could not be parsed
 }
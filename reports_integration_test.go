@@ -43,14 +43,14 @@ func TestIntegration_Reports_QueryCriteria(t *testing.T) {
 
 	// make a first test payment
 	request1 := createCardRequest()
-	request1.Amount = 100.00
+	request1.Amount = MustParseMoney("100.00")
 	trans, err := gateway.Payments().MakePayment(request1)
 	assert.Nil(t, err)
 	assert.NotNil(t, trans)
 
 	// make a second test payment
 	request2 := createCardRequest()
-	request2.Amount = 200.00
+	request2.Amount = MustParseMoney("200.00")
 	trans2, err2 := gateway.Payments().MakePayment(request2)
 	assert.Nil(t, err2)
 	assert.NotNil(t, trans2)
@@ -81,7 +81,7 @@ func TestIntegration_Reports_QueryCriteriaStringEquals(t *testing.T) {
 
 	// make a first test payment
 	request1 := createCardRequest()
-	request1.Amount = 100.00
+	request1.Amount = MustParseMoney("100.00")
 	orderNum := request1.OrderNumber
 	trans, err := gateway.Payments().MakePayment(request1)
 	assert.Nil(t, err)
@@ -89,7 +89,7 @@ func TestIntegration_Reports_QueryCriteriaStringEquals(t *testing.T) {
 
 	// make a second test payment
 	request2 := createCardRequest()
-	request2.Amount = 200.00
+	request2.Amount = MustParseMoney("200.00")
 	trans2, err2 := gateway.Payments().MakePayment(request2)
 	assert.Nil(t, err2)
 	assert.NotNil(t, trans2)
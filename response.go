@@ -0,0 +1,99 @@
+package beanstream
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+/*
+ResponseInfo is exactly what the gateway sent back for a call, kept for audits
+and disputes. Set Gateway.CaptureResponses to attach one to every result that
+has a Response field and to every *BeanstreamApiException. For calls that
+return a slice, such as Query and GetCards, use CaptureResponse.
+
+With retries enabled it describes the last attempt.
+*/
+type ResponseInfo struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte        // the raw response body, before decoding
+	Duration   time.Duration // from sending the request to reading the whole response
+	RequestId  string        // the gateway's request ID header, if it sent one
+}
+
+// requestIdHeaders are the headers a request ID may arrive in, in order of preference.
+var requestIdHeaders = []string{"X-Request-Id", "Request-Id", "X-Correlation-Id"}
+
+func newResponseInfo(resp *http.Response, body []byte, duration time.Duration) *ResponseInfo {
+	info := &ResponseInfo{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		Duration:   duration}
+	for _, name := range requestIdHeaders {
+		if id := resp.Header.Get(name); id != "" {
+			info.RequestId = id
+			break
+		}
+	}
+	return info
+}
+
+type responseKey struct{}
+
+/*
+CaptureResponse returns a context that records the gateway's response to any
+call made with it, whether or not Gateway.CaptureResponses is set:
+	ctx, info := beanstream.CaptureResponse(ctx)
+	records, err := gateway.Reports().QueryContext(ctx, start, end, 1, 100)
+	audit.Save(info.RequestId, info.Body)
+info is filled in once the call returns, unless no response was received. Use
+a new context for each call.
+*/
+func CaptureResponse(ctx context.Context) (context.Context, *ResponseInfo) {
+	info := &ResponseInfo{}
+	return context.WithValue(ctx, responseKey{}, info), info
+}
+
+// responseHolder is implemented by result types with a Response field.
+type responseHolder interface {
+	setResponse(info *ResponseInfo)
+}
+
+func (t *PaymentResponse) setResponse(info *ResponseInfo) { t.Response = info }
+func (t *Transaction) setResponse(info *ResponseInfo)     { t.Response = info }
+func (p *Profile) setResponse(info *ResponseInfo)         { p.Response = info }
+func (p *ProfileResponse) setResponse(info *ResponseInfo) { p.Response = info }
+
+// captured reports the response to the context's recorder, if any, and returns
+// it when the transport attaches responses to results and errors.
+func (t transport) captured(ctx context.Context, resp *http.Response, body []byte, duration time.Duration) *ResponseInfo {
+	recorder, _ := ctx.Value(responseKey{}).(*ResponseInfo)
+	if recorder == nil && !t.capture {
+		return nil
+	}
+	info := newResponseInfo(resp, body, duration)
+	if recorder != nil {
+		*recorder = *info
+	}
+	if !t.capture {
+		return nil
+	}
+	return info
+}
+
+// attach sets the captured response on a result or a *BeanstreamApiException.
+func attach(info *ResponseInfo, v interface{}) {
+	if info == nil {
+		return
+	}
+	switch r := v.(type) {
+	case *BeanstreamApiException:
+		r.Response = info
+	case *RedirectException:
+		r.Response = info
+	case responseHolder:
+		r.setResponse(info)
+	}
+}
@@ -0,0 +1,65 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUnit_Response_CapturedOnResult(t *testing.T) {
+	body := `{"id":"10000001","approved":"1"}`
+	fake := (&fakeGateway{Header: http.Header{"X-Request-Id": {"req-123"}}}).on("", "", 200, body)
+	gateway := Gateway{
+		Config:           DefaultConfig(),
+		HTTPClient:       fake.client(),
+		CaptureResponses: true}
+
+	res, err := gateway.Payments().MakePayment(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.NotNil(t, res.Response)
+	assert.Equal(t, 200, res.Response.StatusCode)
+	assert.Equal(t, body, string(res.Response.Body))
+	assert.Equal(t, "req-123", res.Response.RequestId)
+	assert.Equal(t, "req-123", res.Response.Header.Get("X-Request-Id"))
+	assert.True(t, res.Response.Duration > 0)
+}
+
+func TestUnit_Response_CapturedOnError(t *testing.T) {
+	body := `{"code":7,"category":1,"message":"DECLINE"}`
+	gateway := Gateway{
+		Config:           DefaultConfig(),
+		HTTPClient:       (&fakeGateway{}).on("", "", 402, body).client(),
+		CaptureResponses: true}
+
+	_, err := gateway.Payments().MakePayment(PaymentRequest{})
+	apiErr := err.(*BeanstreamApiException)
+	assert.Equal(t, 7, apiErr.Code)
+	assert.NotNil(t, apiErr.Response)
+	assert.Equal(t, 402, apiErr.Response.StatusCode)
+	assert.Equal(t, body, string(apiErr.Response.Body))
+}
+
+func TestUnit_Response_OffByDefault(t *testing.T) {
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: (&fakeGateway{}).on("", "", 200, `{"id":"1"}`).client()}
+	res, err := gateway.Payments().MakePayment(PaymentRequest{})
+	assert.Nil(t, err)
+	assert.Nil(t, res.Response)
+}
+
+func TestUnit_Response_CaptureContext(t *testing.T) {
+	body := `{"records":[{"trn_id":1}]}`
+	fake := (&fakeGateway{Header: http.Header{"X-Request-Id": {"req-123"}}}).on("", "", 200, body)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client()}
+
+	ctx, info := CaptureResponse(context.Background())
+	records, err := gateway.Reports().QueryContext(ctx, time.Now().Add(-time.Hour), time.Now(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, 200, info.StatusCode)
+	assert.Equal(t, body, string(info.Body))
+	assert.Equal(t, "req-123", info.RequestId)
+}
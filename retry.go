@@ -0,0 +1,69 @@
+package beanstream
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+/*
+RetryPolicy controls how the SDK retries calls that only read data:
+GetTransaction, GetProfile, GetCards, GetCard and Query. Calls that create or
+change a payment or a profile are never retried, since repeating them could
+charge a customer twice.
+
+A read is retried when the connection fails or when the gateway answers with
+a 5xx InternalServerException. Waits between attempts grow exponentially from
+BaseDelay up to MaxDelay, with random jitter so that many clients do not retry
+in lockstep.
+
+The zero value makes a single attempt. Use DefaultRetryPolicy() for sensible
+defaults and set it on the Gateway:
+	gateway.Retry = beanstream.DefaultRetryPolicy()
+*/
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first one
+	MaxElapsed  time.Duration // no retry is started after this much time; 0 for no limit
+	BaseDelay   time.Duration // wait before the first retry, doubled for each one after
+	MaxDelay    time.Duration // longest single wait between attempts
+}
+
+// DefaultRetryPolicy makes up to 3 attempts within 10 seconds.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		MaxElapsed:  10 * time.Second,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second}
+}
+
+// backoff returns how long to wait after the given failed attempt (starting at 1).
+// It uses "equal jitter": half of the exponential delay plus a random share of
+// the other half.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// isRetryable reports whether a failed read is worth repeating.
+func isRetryable(err error) bool {
+	switch e := err.(type) {
+	case *RequestException:
+		// a cancelled or expired context will fail again immediately
+		return !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, context.DeadlineExceeded)
+	case *BeanstreamApiException:
+		return e.Status >= 500 && e.ErrorType() == "InternalServerException"
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package beanstream
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+ThreeDSecure is the 3-D Secure block of a card payment, sent as
+CreditCard.ThreeDSecure.
+
+To have the gateway authenticate the customer with 3-D Secure 2, set Enabled,
+Version 2 and the customer's Browser, along with TermUrl and CustomerIp on the
+PaymentRequest. If the issuer wants to challenge the customer, MakePayment
+returns a *RedirectException; see ContinuePayment.
+
+If you authenticated the customer with your own 3-D Secure provider instead,
+pass its results in Cavv, Eci and Xid.
+*/
+type ThreeDSecure struct {
+	Enabled      bool     `json:"enabled,omitempty"`
+	Version      int      `json:"version,omitempty"`       // 1 or 2
+	AuthRequired bool     `json:"auth_required,omitempty"` // decline if the customer cannot be authenticated
+	Browser      *Browser `json:"browser,omitempty"`
+
+	// results of authentication done outside the gateway
+	Cavv string `json:"cavv,omitempty"` // cardholder authentication verification value
+	Eci  int    `json:"eci,omitempty"`  // electronic commerce indicator
+	Xid  string `json:"xid,omitempty"`  // 3-D Secure transaction ID
+}
+
+/*
+Browser describes the customer's browser for 3-D Secure 2 risk checks. The
+headers can be read on the server; the screen, time zone and Java values only
+exist in the browser, so have your checkout page's script post them along with
+the order, then call BrowserFromRequest.
+*/
+type Browser struct {
+	AcceptHeader      string `json:"accept_header,omitempty"`
+	UserAgent         string `json:"user_agent,omitempty"`
+	Language          string `json:"language,omitempty"`      // eg en-US
+	ColorDepth        int    `json:"color_depth,omitempty"`   // screen.colorDepth
+	ScreenHeight      int    `json:"screen_height,omitempty"` // screen.height
+	ScreenWidth       int    `json:"screen_width,omitempty"`  // screen.width
+	TimeZone          int    `json:"time_zone"`               // new Date().getTimezoneOffset(), in minutes
+	JavaEnabled       bool   `json:"java_enabled"`            // navigator.javaEnabled()
+	JavascriptEnabled bool   `json:"javascript_enabled"`
+}
+
+/*
+BrowserFromRequest collects the customer's browser data from the request their
+browser made to your checkout. The Accept, User-Agent and Accept-Language
+headers are always read. The values a script has to gather are read from these
+form fields, when present:
+	color_depth     screen.colorDepth
+	screen_height   screen.height
+	screen_width    screen.width
+	time_zone       new Date().getTimezoneOffset()
+	java_enabled    navigator.javaEnabled()
+Since only a script can post them, JavascriptEnabled is set when any of them is.
+*/
+func BrowserFromRequest(r *http.Request) *Browser {
+	b := &Browser{
+		AcceptHeader: r.Header.Get("Accept"),
+		UserAgent:    r.Header.Get("User-Agent"),
+		Language:     firstLanguage(r.Header.Get("Accept-Language"))}
+
+	ints := []struct {
+		name  string
+		field *int
+	}{
+		{"color_depth", &b.ColorDepth},
+		{"screen_height", &b.ScreenHeight},
+		{"screen_width", &b.ScreenWidth},
+		{"time_zone", &b.TimeZone}}
+	for _, v := range ints {
+		if val := r.FormValue(v.name); val != "" {
+			if n, err := strconv.Atoi(val); err == nil {
+				*v.field = n
+				b.JavascriptEnabled = true
+			}
+		}
+	}
+	if val := r.FormValue("java_enabled"); val != "" {
+		b.JavaEnabled, _ = strconv.ParseBool(val)
+		b.JavascriptEnabled = true
+	}
+	return b
+}
+
+// firstLanguage picks the preferred tag of an Accept-Language header, eg
+// "en-US" from "en-US,en;q=0.9".
+func firstLanguage(header string) string {
+	lang := strings.SplitN(header, ",", 2)[0]
+	return strings.TrimSpace(strings.SplitN(lang, ";", 2)[0])
+}
@@ -0,0 +1,60 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestUnit_ThreeDSecure_BrowserFromRequest(t *testing.T) {
+	form := url.Values{"color_depth": {"24"}, "screen_height": {"1080"}, "screen_width": {"1920"}, "time_zone": {"-120"}, "java_enabled": {"false"}}
+	r := httptest.NewRequest("POST", "/checkout", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "text/html")
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	r.Header.Set("Accept-Language", "fr-CA,fr;q=0.9,en;q=0.8")
+
+	b := BrowserFromRequest(r)
+	assert.Equal(t, Browser{
+		AcceptHeader:      "text/html",
+		UserAgent:         "Mozilla/5.0",
+		Language:          "fr-CA",
+		ColorDepth:        24,
+		ScreenHeight:      1080,
+		ScreenWidth:       1920,
+		TimeZone:          -120,
+		JavaEnabled:       false,
+		JavascriptEnabled: true}, *b)
+
+	b = BrowserFromRequest(httptest.NewRequest("GET", "/checkout", nil))
+	assert.False(t, b.JavascriptEnabled)
+	assert.Equal(t, "", b.Language)
+}
+
+func TestUnit_ThreeDSecure_JSON(t *testing.T) {
+	card := CreditCard{Number: "4012000033330026", ThreeDSecure: &ThreeDSecure{
+		Enabled: true,
+		Version: 2,
+		Browser: &Browser{UserAgent: "Mozilla/5.0", JavascriptEnabled: true}}}
+	b, _ := json.Marshal(card)
+	var out map[string]interface{}
+	json.Unmarshal(b, &out)
+	tds := out["3d_secure"].(map[string]interface{})
+	assert.Equal(t, true, tds["enabled"])
+	assert.Equal(t, float64(2), tds["version"])
+	assert.Equal(t, "Mozilla/5.0", tds["browser"].(map[string]interface{})["user_agent"])
+
+	b, _ = json.Marshal(CreditCard{})
+	assert.False(t, strings.Contains(string(b), "3d_secure"))
+}
+
+func TestUnit_ThreeDSecure_Redacted(t *testing.T) {
+	body := redactBody([]byte(`{"card":{"3d_secure":{"cavv":"AAABBEg0VhI0VniQEjRWAAAAAAA=","xid":"MDAwMDAwMDAwMDAwMDAwMzIyNzY=","eci":5}}}`))
+	assert.False(t, strings.Contains(body, "AAABBEg0"))
+	assert.False(t, strings.Contains(body, "MDAwMDAw"))
+}
@@ -2,193 +2,388 @@ package beanstream
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-func ProcessBody(httpMethod string, url string, merchId string, apiKey string, data interface{}, responseType interface{}) (interface{}, error) {
+// defaultHTTPClient is shared by every API that was not given its own client,
+// so keep-alive connections to the gateway are pooled across calls.
+var defaultHTTPClient = &http.Client{Timeout: 60 * time.Second, CheckRedirect: noRedirects}
 
-	jsonData, _ := json.Marshal(data)
-	//fmt.Println("--> Request: ", string(jsonData))
-	//fmt.Println("Url: ", url)
-	passcode := GenerateAuthCode(merchId, apiKey)
-	req, err := http.NewRequest(httpMethod, url, bytes.NewBuffer(jsonData))
-	//fmt.Println("Authorization: " + passcode)
-	req.Header.Set("Authorization", "Passcode "+passcode)
-	req.Header.Set("Content-Type", "application/json")
+// noRedirects keeps a 302 from the gateway as the response, so that it becomes
+// a RedirectException rather than being followed to its Location.
+func noRedirects(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
+// transport carries the per-gateway request settings that every API object
+// inherits from its Gateway. The zero value uses defaultHTTPClient.
+type transport struct {
+	client       *http.Client
+	retry        RetryPolicy
+	logger       Logger
+	interceptors []Interceptor
+	capture      bool // attach a ResponseInfo to results and errors
+}
 
-	body, _ := ioutil.ReadAll(resp.Body)
-	//fmt.Println("<-- Response:", string(body))
-	//fmt.Println("response Status:", resp.Status)
+/*
+call describes a single gateway operation. idempotent marks the calls send may
+retry: those that give the same result however many times the gateway receives
+them. That is the reads (GetTransaction, GetProfile, GetCards, GetCard and Query,
+though Query is a POST) and nothing that creates, changes or deletes a payment,
+profile or card.
+*/
+type call struct {
+	op         string // one of the Op constants; empty for direct Process calls
+	method     string
+	url        string
+	config     Config
+	api        ApiName // whose passcode to send; empty for none
+	idempotent bool
+}
 
-	// handle errors
-	if resp.StatusCode != 200 {
-		return nil, handleError(resp, body)
+func (t transport) httpClient() *http.Client {
+	if t.client != nil {
+		return t.client
 	}
+	return defaultHTTPClient
+}
+
+func ProcessBody(httpMethod string, url string, merchId string, apiKey string, data interface{}, responseType interface{}) (interface{}, error) {
+	return ProcessBodyContext(context.Background(), httpMethod, url, merchId, apiKey, data, responseType)
+}
+
+// ProcessBodyContext is ProcessBody with a context. If ctx is cancelled or its
+// deadline passes, the in-flight request is aborted and a *RequestException
+// wrapping ctx.Err() is returned.
+func ProcessBodyContext(ctx context.Context, httpMethod string, url string, merchId string, apiKey string, data interface{}, responseType interface{}) (interface{}, error) {
+	return transport{}.processBody(ctx, call{"", httpMethod, url, Config{MerchantId: merchId, PaymentsApiKey: apiKey}, PaymentsApi, false}, data, responseType)
+}
 
-	err = json.Unmarshal([]byte(body), &responseType)
+func (t transport) processBody(ctx context.Context, c call, data interface{}, responseType interface{}) (interface{}, error) {
+
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return nil, &BeanstreamApiException{resp.StatusCode, 0, 0, err.Error(), "Error parsing Json response", nil}
+		return nil, &RequestException{NotSent, "cannot encode request", err}
 	}
-
-	//fmt.Printf("responseType: %T : %v\n", responseType, responseType)
-	return responseType, nil
+	return t.send(ctx, c, "application/json", jsonData, responseType)
 }
 
 func ProcessMultiPart(httpMethod string, url string, merchId string, apiKey string, responseType interface{}, jsonCriteria interface{}, batchFile string) (interface{}, error) {
+	return ProcessMultiPartContext(context.Background(), httpMethod, url, merchId, apiKey, responseType, jsonCriteria, batchFile)
+}
 
-	jsonData, _ := json.Marshal(jsonCriteria)
-	fmt.Println("--> Request: ", string(jsonData))
-	fmt.Println("Url: ", url)
-	passcode := GenerateAuthCode(merchId, apiKey)
+// ProcessMultiPartContext is ProcessMultiPart with a context.
+func ProcessMultiPartContext(ctx context.Context, httpMethod string, url string, merchId string, apiKey string, responseType interface{}, jsonCriteria interface{}, batchFile string) (interface{}, error) {
+	return transport{}.processMultiPart(ctx, call{"", httpMethod, url, Config{MerchantId: merchId, PaymentsApiKey: apiKey}, PaymentsApi, false}, responseType, jsonCriteria, batchFile)
+}
+
+func (t transport) processMultiPart(ctx context.Context, c call, responseType interface{}, jsonCriteria interface{}, batchFile string) (interface{}, error) {
+
+	jsonData, err := json.Marshal(jsonCriteria)
+	if err != nil {
+		return nil, &RequestException{NotSent, "cannot encode batch criteria", err}
+	}
 
 	// multipart/form-data section:
 	var b bytes.Buffer
 	w := multipart.NewWriter(&b)
+	if err = writeBatchForm(w, jsonData, batchFile); err != nil {
+		return nil, &RequestException{NotSent, "cannot build multipart request", err}
+	}
+
+	return t.send(ctx, c, w.FormDataContentType(), b.Bytes(), responseType)
+}
+
+func Process(httpMethod string, url string, merchId string, apiKey string, responseType interface{}) (interface{}, error) {
+	return ProcessContext(context.Background(), httpMethod, url, merchId, apiKey, responseType)
+}
+
+// ProcessContext is Process with a context.
+func ProcessContext(ctx context.Context, httpMethod string, url string, merchId string, apiKey string, responseType interface{}) (interface{}, error) {
+	return transport{}.process(ctx, call{"", httpMethod, url, Config{MerchantId: merchId, PaymentsApiKey: apiKey}, PaymentsApi, false}, responseType)
+}
 
+// writeBatchForm writes the criteria, type and batch file parts of a batch upload.
+func writeBatchForm(w *multipart.Writer, jsonData []byte, batchFile string) error {
 	// add the json 'criteria'
 	fw, err := w.CreateFormField("criteria")
 	if err != nil {
-		panic(err)
+		return err
 	}
 	if _, err = fw.Write(jsonData); err != nil {
-		panic(err)
+		return err
 	}
 
 	// add the json type 'type'
 	if fw, err = w.CreateFormField("type"); err != nil {
-		panic(err)
+		return err
 	}
 	if _, err = fw.Write([]byte("application/json")); err != nil {
-		panic(err)
+		return err
 	}
 
 	// add the batch file 'filename'
 	f, err := os.Open(batchFile)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer f.Close()
 	if fw, err = w.CreateFormFile("filename", batchFile); err != nil {
-		panic(err)
+		return err
 	}
-
 	if _, err = io.Copy(fw, f); err != nil {
-		panic(err)
+		return err
 	}
 
-	err = w.Close() // close multipart writer
+	return w.Close() // close multipart writer
+}
+
+func (t transport) process(ctx context.Context, c call, responseType interface{}) (interface{}, error) {
+	return t.send(ctx, c, "application/json", nil, responseType)
+}
+
+/*
+decodeHook is implemented by response types that need more work once the
+JSON is decoded, such as parsing the gateway's timestamps. It cannot fail: a
+response the gateway sent is always handed back, and a field that cannot be
+parsed is left zero with the reason recorded on the response (see DateErr),
+so that an approved payment is never reported as an error and retried.
+*/
+type decodeHook interface {
+	afterDecode(config Config)
+}
+
+// execute makes the call and decodes its response into a new T. If data is
+// not nil it is sent as the JSON request body.
+func execute[T any](ctx context.Context, t transport, c call, data interface{}) (*T, error) {
+	var res interface{}
+	var err error
+	if data == nil {
+		res, err = t.process(ctx, c, new(T))
+	} else {
+		res, err = t.processBody(ctx, c, data, new(T))
+	}
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	out, ok := res.(*T)
+	if !ok {
+		// only an Interceptor that replaced the response can cause this
+		return nil, &BeanstreamApiException{-1, 0, 0, fmt.Sprintf("expected a %T response, got %T", out, res), "", nil, nil}
+	}
+	return out, nil
+}
+
+// send is the request path shared by execute and ProcessBody, Process and
+// ProcessMultiPart. It issues the request under ctx and decodes a 200 response
+// into responseType.
+// Idempotent calls are retried according to the transport's RetryPolicy, and
+// a 401 is retried once after refreshing the config's CredentialRefresher.
+func (t transport) send(ctx context.Context, c call, contentType string, body []byte, responseType interface{}) (interface{}, error) {
+	res, err := t.sendRetrying(ctx, c, contentType, body, responseType)
+	refresher, ok := c.config.Credentials.(CredentialRefresher)
+	if !ok || c.api == "" || !isUnauthorized(err) {
+		return res, err
 	}
+	// the gateway turned the request away, so it is safe to repeat it once
+	// with fresh credentials even when it is not idempotent
+	if err := refresher.Refresh(ctx, c.api); err != nil {
+		return nil, &RequestException{NotSent, "cannot refresh credentials", err}
+	}
+	return t.sendRetrying(ctx, c, contentType, body, responseType)
+}
 
-	req, err := http.NewRequest(httpMethod, url, &b) // write the byte buffer
+// sendRetrying makes the request, repeating idempotent calls per the retry policy.
+func (t transport) sendRetrying(ctx context.Context, c call, contentType string, body []byte, responseType interface{}) (interface{}, error) {
+	if !c.idempotent {
+		return t.attempt(ctx, c, contentType, body, responseType)
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		res, err := t.attempt(ctx, c, contentType, body, responseType)
+		if err == nil || attempt >= t.retry.MaxAttempts || !isRetryable(err) {
+			return res, err
+		}
+		delay := t.retry.backoff(attempt)
+		if t.retry.MaxElapsed > 0 && time.Since(start)+delay > t.retry.MaxElapsed {
+			return res, err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RequestException{NotSent, "request cancelled", ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes one request to the gateway, passing it through the interceptors.
+func (t transport) attempt(ctx context.Context, c call, contentType string, body []byte, responseType interface{}) (interface{}, error) {
+
+	merchId, apiKey, err := c.config.credentials(ctx, c.api)
+	if err != nil {
+		return nil, &RequestException{NotSent, "cannot get credentials", err}
+	}
+	passcode := GenerateAuthCode(merchId, apiKey)
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, c.method, c.url, reqBody)
+	if err != nil {
+		return nil, &RequestException{NotSent, "cannot create request", err}
+	}
 	req.Header.Set("Authorization", "Passcode "+passcode)
-	//req.Header.Set("Content-Type", "multipart/form-data")
-	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Content-Type", contentType)
+
+	invoke := t.chain(c.op, func(ctx context.Context, req *http.Request) (interface{}, error) {
+		return t.roundTrip(ctx, c, req, body, responseType)
+	})
+	return invoke(ctx, req)
+}
 
-	client := &http.Client{}
+// roundTrip sends req and decodes a 200 response into responseType, running
+// its decodeHook if it has one. body is the request body, kept for logging.
+func (t transport) roundTrip(ctx context.Context, c call, req *http.Request, body []byte, responseType interface{}) (interface{}, error) {
+	if ctx.Err() != nil {
+		return nil, &RequestException{NotSent, "request cancelled", ctx.Err()}
+	}
+	trace := &sendTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()))
+	client := t.httpClient()
+	if client.CheckRedirect == nil {
+		noFollow := *client
+		noFollow.CheckRedirect = noRedirects
+		client = &noFollow
+	}
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		panic(err) //return nil, err
+		if ctx.Err() != nil {
+			// report the cancellation itself rather than the *url.Error around it
+			err = ctx.Err()
+		}
+		reqErr := &RequestException{trace.outcome(err), "request failed", err}
+		t.log(req, body, 0, nil, time.Since(start), reqErr)
+		return nil, reqErr
 	}
 	defer resp.Body.Close()
 
-	body, _ := ioutil.ReadAll(resp.Body)
-	//fmt.Println("<-- Response:", string(body))
-	//fmt.Println("response Status:", resp.Status)
-
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		reqErr := &RequestException{OutcomeUnknown, "cannot read response", err}
+		t.log(req, body, resp.StatusCode, nil, time.Since(start), reqErr)
+		return nil, reqErr
+	}
+	latency := time.Since(start)
+	t.log(req, body, resp.StatusCode, respBody, latency, nil)
+	info := t.captured(ctx, resp, respBody, latency)
+
+	if resp.StatusCode == http.StatusFound {
+		if redirect := parseRedirect(respBody); redirect != nil {
+			attach(info, redirect)
+			return nil, redirect
+		}
+	}
 	// handle errors
 	if resp.StatusCode != 200 {
-		return nil, handleError(resp, body)
+		apiErr := handleError(resp, respBody)
+		attach(info, apiErr)
+		return nil, apiErr
 	}
 
-	err = json.Unmarshal([]byte(body), &responseType)
+	err = json.Unmarshal(respBody, &responseType)
 	if err != nil {
-		return nil, &BeanstreamApiException{resp.StatusCode, 0, 0, err.Error(), "Error parsing Json response", nil}
+		return nil, &BeanstreamApiException{resp.StatusCode, 0, 0, err.Error(), "Error parsing Json response", nil, info}
+	}
+	attach(info, responseType)
+	if hook, ok := responseType.(decodeHook); ok {
+		hook.afterDecode(c.config)
 	}
 
-	//fmt.Printf("responseType: %T : %v\n", responseType, responseType)
 	return responseType, nil
 }
 
+// sendTrace records how far the http.Transport got with a request, so a failed
+// call can be reported as NotSent or OutcomeUnknown. The callbacks can run on
+// the transport's own goroutines, hence the atomics.
+type sendTrace struct {
+	traced int32 // the transport reported it was getting a connection
+	wrote  int32 // request headers were written to the connection
+}
+
+func (st *sendTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:      func(string) { atomic.StoreInt32(&st.traced, 1) },
+		WroteHeaders: func() { atomic.StoreInt32(&st.wrote, 1) },
+	}
+}
+
+// outcome classifies a failed client.Do. Transports that do not report trace
+// events, such as custom RoundTrippers, are only trusted for DNS and dial errors.
+func (st *sendTrace) outcome(err error) RequestOutcome {
+	if atomic.LoadInt32(&st.traced) == 1 {
+		if atomic.LoadInt32(&st.wrote) == 0 {
+			return NotSent
+		}
+		return OutcomeUnknown
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return NotSent
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return NotSent
+	}
+	return OutcomeUnknown
+}
+
 func handleError(resp *http.Response, body []byte) error {
 	// parse json body
 	ct := resp.Header.Get("Content-Type")
-	fmt.Println("Error content-type: ", ct)
 	if ct == "application/json; charset=utf-8" {
 		errResp := errorResponse{}
 		b := strings.Replace(string(body), "\"reference\":null,", "\"reference\":\"\",", -1)
-		fmt.Println("Error message: ", b)
 		err := json.Unmarshal([]byte(b), &errResp)
 		if err != nil {
-			return &BeanstreamApiException{resp.StatusCode, 0, 0, err.Error(), "Error parsing Json error message", nil}
+			return &BeanstreamApiException{resp.StatusCode, 0, 0, err.Error(), "Error parsing Json error message", nil, nil}
 		}
-		return &BeanstreamApiException{resp.StatusCode, errResp.Code, errResp.Category, errResp.Message, errResp.Reference, errResp.Details}
+		return &BeanstreamApiException{resp.StatusCode, errResp.Code, errResp.Category, errResp.Message, errResp.Reference, errResp.Details, nil}
 	} else {
-		return &BeanstreamApiException{resp.StatusCode, 0, 0, "", "Non-json error message. Content Type(" + ct + ")", nil}
+		return &BeanstreamApiException{resp.StatusCode, 0, 0, "", "Non-json error message. Content Type(" + ct + ")", nil, nil}
 	}
 }
 
-func Process(httpMethod string, url string, merchId string, apiKey string, responseType interface{}) (interface{}, error) {
-
-	//fmt.Println("--> Request ")
-	//fmt.Println("Url: ", url)
-	passcode := GenerateAuthCode(merchId, apiKey)
-	req, err := http.NewRequest(httpMethod, url, nil)
-	req.Header.Set("Authorization", "Passcode "+passcode)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	//fmt.Println("<-- Response:", string(body))
-	//fmt.Println("response Status:", resp.Status)
-
-	// handle errors
-	if resp.StatusCode != 200 {
-		return nil, handleError(resp, body)
-	}
-
-	err = json.Unmarshal([]byte(body), &responseType)
-	if err != nil {
-		return nil, &BeanstreamApiException{resp.StatusCode, 0, 0, err.Error(), "Error parsing Json response", nil}
-	}
-
-	//fmt.Printf("responseType: %T : %v\n", responseType, responseType)
-	return responseType, nil
-}
-
 func GenerateAuthCode(merchId string, apiKey string) string {
 	return base64.StdEncoding.EncodeToString([]byte(string(merchId + ":" + apiKey)))
 }
 
 // JSON:
-// {
-//	"code":200,
-//	"category":2,
-//	"message":"Transaction cannot be adjusted",
-//	"reference":nil
-//	"details":[{"field":"card_name","message":"Card owner name is missing"}]
-// }
+//
+//	{
+//		"code":200,
+//		"category":2,
+//		"message":"Transaction cannot be adjusted",
+//		"reference":nil
+//		"details":[{"field":"card_name","message":"Card owner name is missing"}]
+//	}
 type errorResponse struct {
 	Code      int           `json:"code,omitempty"`
 	Category  int           `json:"category,omitempty"`
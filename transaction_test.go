@@ -3,11 +3,187 @@
 package beanstream
 
 import (
+	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 )
 
 func Test_Transaction_GenerateAuthCode(t *testing.T) {
 	auth := GenerateAuthCode("300200578", "4BaD82D9197b4cc4b70a221911eE9f70")
 	assert.EqualValues(t, auth, "MzAwMjAwNTc4OjRCYUQ4MkQ5MTk3YjRjYzRiNzBhMjIxOTExZUU5Zjcw")
 }
+
+func TestUnit_Transaction_ProcessContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	responseType := Transaction{}
+	res, err := ProcessContext(ctx, http.MethodGet, server.URL, "300200578", "key", &responseType)
+	assert.Nil(t, res)
+	assert.True(t, errors.Is(err, context.Canceled), "Error is not context.Canceled: %v", err)
+	reqErr, ok := err.(*RequestException)
+	assert.True(t, ok, "Error is not a RequestException")
+	assert.Equal(t, OutcomeUnknown, reqErr.Outcome)
+}
+
+func TestUnit_Transaction_ProcessBodyContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	responseType := PaymentResponse{}
+	res, err := ProcessBodyContext(ctx, http.MethodPost, server.URL, "300200578", "key", PaymentRequest{}, &responseType)
+	assert.Nil(t, res)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "Error is not context.DeadlineExceeded: %v", err)
+}
+
+func TestUnit_Transaction_ProcessAlreadyCancelledNotSent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	responseType := Transaction{}
+	_, err := ProcessContext(ctx, http.MethodGet, "http://127.0.0.1:1/payments/1", "300200578", "key", &responseType)
+	reqErr, ok := err.(*RequestException)
+	assert.True(t, ok, "Error is not a RequestException")
+	assert.Equal(t, NotSent, reqErr.Outcome)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestUnit_Transaction_ConnectionRefusedNotSent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	responseType := PaymentResponse{}
+	res, err := ProcessBody(http.MethodPost, url, "300200578", "key", PaymentRequest{}, &responseType)
+	assert.Nil(t, res)
+	reqErr, ok := err.(*RequestException)
+	assert.True(t, ok, "Error is not a RequestException")
+	assert.Equal(t, NotSent, reqErr.Outcome)
+}
+
+func TestUnit_Transaction_EncodeFailureNotSent(t *testing.T) {
+	responseType := PaymentResponse{}
+	_, err := ProcessBody(http.MethodPost, "http://127.0.0.1:1/payments", "300200578", "key", make(chan int), &responseType)
+	reqErr, ok := err.(*RequestException)
+	assert.True(t, ok, "Error is not a RequestException")
+	assert.Equal(t, NotSent, reqErr.Outcome)
+}
+
+func TestUnit_Transaction_MultiPartMissingFileNotSent(t *testing.T) {
+	responseType := PaymentResponse{}
+	_, err := ProcessMultiPart(http.MethodPost, "http://127.0.0.1:1/batchpayments", "300200578", "key", &responseType, BatchCriteria{}, "no-such-batch.csv")
+	reqErr, ok := err.(*RequestException)
+	assert.True(t, ok, "Error is not a RequestException")
+	assert.Equal(t, NotSent, reqErr.Outcome)
+	assert.True(t, os.IsNotExist(errors.Unwrap(err)))
+}
+
+const serverError = `{"code":1,"category":1,"message":"Server error","reference":""}`
+
+func TestUnit_Transaction_RetryIdempotentRead(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on("", "", 500, serverError).
+		on("", "", 503, serverError).
+		on("", "", 200, `{"order_number":"TEST1"}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+	res, err := gateway.Payments().GetTransaction("10000001")
+	assert.Nil(t, err, "Unexpected error occurred.", err)
+	assert.Equal(t, "TEST1", res.OrderNumber)
+	assert.Equal(t, 3, len(fake.received()))
+}
+
+func TestUnit_Transaction_RetryGivesUpAfterMaxAttempts(t *testing.T) {
+	fake := (&fakeGateway{}).on("", "", 500, serverError)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), Retry: RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}}
+	_, err := gateway.Reports().Query(time.Now().Add(-time.Hour), time.Now(), 1, 2)
+	apiErr, ok := err.(*BeanstreamApiException)
+	assert.True(t, ok, "Error is not a BeanstreamApiException")
+	assert.Equal(t, 500, apiErr.Status)
+	assert.Equal(t, 2, len(fake.received()))
+}
+
+func TestUnit_Transaction_NoRetryForPayments(t *testing.T) {
+	fake := (&fakeGateway{}).on("", "", 500, serverError).on("", "", 200, `{}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+	_, err := gateway.Payments().MakePayment(PaymentRequest{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(fake.received()))
+}
+
+func TestUnit_Transaction_NoRetryForClientErrors(t *testing.T) {
+	fake := (&fakeGateway{}).on("", "", 404, serverError).on("", "", 200, `{}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+	_, err := gateway.Profiles().GetProfile("ABC")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(fake.received()))
+}
+
+func TestUnit_Transaction_RetryTimeBudget(t *testing.T) {
+	fake := (&fakeGateway{}).on("", "", 500, serverError).on("", "", 200, `{}`)
+	gateway := Gateway{Config: DefaultConfig(), HTTPClient: fake.client(), Retry: RetryPolicy{MaxAttempts: 3, MaxElapsed: 10 * time.Millisecond, BaseDelay: time.Second}}
+	_, err := gateway.Profiles().GetCards("ABC")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(fake.received()))
+}
+
+func TestUnit_Transaction_RetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		delay := policy.backoff(attempt + 1)
+		max *= time.Millisecond
+		assert.True(t, delay >= max/2 && delay <= max, "attempt %v waited %v", attempt+1, delay)
+	}
+}
+
+type hookedResponse struct {
+	OrderNumber string `json:"order_number"`
+	merchantId  string
+}
+
+func (h *hookedResponse) afterDecode(config Config) {
+	h.merchantId = config.MerchantId
+}
+
+func TestUnit_Transaction_ExecuteTyped(t *testing.T) {
+	fake := (&fakeGateway{}).on(http.MethodGet, "/payments/1", 200, `{"order_number":"TEST1"}`)
+	tr := transport{client: fake.client()}
+	c := call{OpGetTransaction, http.MethodGet, "https://www.beanstream.com/api/v1/payments/1", Config{MerchantId: "300200578"}, PaymentsApi, true}
+	res, err := execute[hookedResponse](context.Background(), tr, c, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "TEST1", res.OrderNumber)
+	assert.Equal(t, "300200578", res.merchantId, "decode hook was not run")
+}
+
+func TestUnit_Transaction_ExecuteWrongResponseType(t *testing.T) {
+	fake := &fakeGateway{}
+	swap := func(ctx context.Context, op string, req *http.Request, next Invoker) (interface{}, error) {
+		next(ctx, req)
+		return &ProfileResponse{}, nil
+	}
+	tr := transport{client: fake.client(), interceptors: []Interceptor{swap}}
+	c := call{OpMakePayment, http.MethodPost, "https://www.beanstream.com/api/v1/payments", Config{}, PaymentsApi, false}
+	res, err := execute[PaymentResponse](context.Background(), tr, c, PaymentRequest{})
+	assert.Nil(t, res)
+	apiErr, ok := err.(*BeanstreamApiException)
+	assert.True(t, ok, "Error is not a BeanstreamApiException")
+	assert.Equal(t, "UnexpectedException", apiErr.ErrorType())
+}
@@ -19,13 +19,15 @@ func Util_randSeq(n int) string {
 
 func Util_randOrderId(num int) string {
 	rnd := Util_randSeq(num)
-	//fmt.Println("Timestamp: ", strconv.Itoa(int(time.Now().Unix())))
 	rnd += strconv.Itoa(int(time.Now().Unix()))
 	return rnd
 }
 
+// AsDate reads a gateway timestamp in the time zone of config, giving the zero
+// time if it cannot be parsed.
+//
+// Deprecated: use ParseDate, which reports the error.
 func AsDate(val string, config Config) time.Time {
-	rfc3339Time := val + "Z" + config.TimezoneOffset
-	t, _ := time.Parse(time.RFC3339, rfc3339Time)
+	t, _ := ParseDate(val, config)
 	return t
 }
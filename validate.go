@@ -0,0 +1,139 @@
+package beanstream
+
+import (
+	"fmt"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"regexp"
+)
+
+const (
+	maxOrderNumberLength = 30
+	maxCustomFieldLength = 256
+)
+
+var orderNumberPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]*$`)
+
+/*
+Validate checks the request the way the gateway would, without sending it. It
+returns a *BeanstreamApiException with Status 400 and an ErrorDetail for every
+problem, or nil if there are none:
+	if err := request.Validate(); err != nil {
+		for _, detail := range err.(*beanstream.BeanstreamApiException).Details {
+			form.SetError(detail.Field, detail.Message)
+		}
+	}
+The rules depend on PaymentMethod:
+	card             Card needs a number, name and expiry date
+	token            Token needs its code and the cardholder's name
+	payment_profile  Profile needs the profile ID and a card ID of 1 or more
+	cash, cheque     no card, token or profile data
+	interac          no card, token or profile data; the bank collects it
+Only the data of the chosen method may be set, apart from the Complete flags.
+Amount must be positive, OrderNumber at most 30 letters, digits or any of
+"-_./", and each custom field at most 256 characters. Line items must add up
+to Amount; see LineItem.
+*/
+func (request PaymentRequest) Validate() error {
+	var details []ErrorDetail
+	add := func(field, message string) {
+		details = append(details, ErrorDetail{field, message})
+	}
+
+	hasCard, hasToken, hasProfile := !request.Card.empty(), !request.Token.empty(), !request.Profile.empty()
+	switch request.PaymentMethod {
+	case "":
+		add("payment_method", "is required")
+	case paymentMethods.CARD:
+		if request.Card.Number == "" {
+			add("card.number", "is required")
+		}
+		if request.Card.Name == "" {
+			add("card.name", "is required")
+		}
+		if request.Card.ExpiryMonth == "" {
+			add("card.expiry_month", "is required")
+		}
+		if request.Card.ExpiryYear == "" {
+			add("card.expiry_year", "is required")
+		}
+		hasCard = false
+	case paymentMethods.TOKEN:
+		if request.Token.Token == "" {
+			add("token.code", "is required")
+		}
+		if request.Token.Name == "" {
+			add("token.name", "is required")
+		}
+		hasToken = false
+	case paymentMethods.PROFILE:
+		if request.Profile.ProfileId == "" {
+			add("payment_profile.customer_code", "is required")
+		}
+		if request.Profile.CardId < 1 {
+			add("payment_profile.card_id", "must be 1 or more")
+		}
+		hasProfile = false
+	case paymentMethods.CASH, paymentMethods.CHEQUE, paymentMethods.INTERAC:
+	default:
+		add("payment_method", fmt.Sprintf("%q is not a payment method", request.PaymentMethod))
+	}
+	if request.PaymentMethod != "" {
+		if hasCard {
+			add("card", fmt.Sprintf("is not allowed for %v payments", request.PaymentMethod))
+		}
+		if hasToken {
+			add("token", fmt.Sprintf("is not allowed for %v payments", request.PaymentMethod))
+		}
+		if hasProfile {
+			add("payment_profile", fmt.Sprintf("is not allowed for %v payments", request.PaymentMethod))
+		}
+	}
+
+	if request.Amount <= 0 {
+		add("amount", "must be greater than 0")
+	}
+	if len(request.OrderNumber) > maxOrderNumberLength {
+		add("order_number", fmt.Sprintf("must be at most %d characters", maxOrderNumberLength))
+	}
+	if !orderNumberPattern.MatchString(request.OrderNumber) {
+		add("order_number", `may only contain letters, digits and "-_./"`)
+	}
+	custom := []struct{ field, value string }{
+		{"custom.ref1", request.Custom.Ref1},
+		{"custom.ref2", request.Custom.Ref2},
+		{"custom.ref3", request.Custom.Ref3},
+		{"custom.ref4", request.Custom.Ref4},
+		{"custom.ref5", request.Custom.Ref5}}
+	for _, c := range custom {
+		if len([]rune(c.value)) > maxCustomFieldLength {
+			add(c.field, fmt.Sprintf("must be at most %d characters", maxCustomFieldLength))
+		}
+	}
+	details = append(details, lineItemDetails(request)...)
+
+	if len(details) == 0 {
+		return nil
+	}
+	return &BeanstreamApiException{
+		Status:  400,
+		Message: "invalid payment request",
+		Details: details}
+}
+
+// empty reports whether no card data is set, ignoring Complete.
+func (c CreditCard) empty() bool {
+	c.Complete = false
+	return c == CreditCard{}
+}
+
+// empty reports whether no token data is set, ignoring Complete.
+func (t Token) empty() bool {
+	t.Complete = false
+	return t == Token{}
+}
+
+// empty reports whether no profile data is set, ignoring Complete.
+func (p ProfilePayment) empty() bool {
+	p.Complete = false
+	return p == ProfilePayment{}
+}
@@ -0,0 +1,69 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func validationDetails(t *testing.T, request PaymentRequest) []ErrorDetail {
+	err := request.Validate()
+	if err == nil {
+		return nil
+	}
+	apiErr, ok := err.(*BeanstreamApiException)
+	assert.True(t, ok)
+	assert.Equal(t, 400, apiErr.Status)
+	return apiErr.Details
+}
+
+func TestUnit_Validate_Valid(t *testing.T) {
+	card := CreditCard{Name: "John Doe", Number: "5100000010001004", ExpiryMonth: "11", ExpiryYear: "19", Cvd: "123", Complete: true}
+	assert.Nil(t, validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.CARD, OrderNumber: "ORDER-1/2", Amount: MustParseMoney("12.99"), Card: card}))
+	assert.Nil(t, validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.TOKEN, Amount: MustParseMoney("1.00"), Token: Token{Token: "abc", Name: "John Doe"}}))
+	assert.Nil(t, validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.PROFILE, Amount: MustParseMoney("1.00"), Profile: ProfilePayment{ProfileId: "PROFILE1", CardId: 1}}))
+	// Complete flags alone are not card data
+	assert.Nil(t, validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.CASH, Amount: MustParseMoney("1.00"), Card: CreditCard{Complete: true}}))
+}
+
+func TestUnit_Validate_PerMethod(t *testing.T) {
+	assert.Equal(t, []ErrorDetail{
+		{"card.number", "is required"},
+		{"card.expiry_month", "is required"},
+		{"card.expiry_year", "is required"},
+		{"token", "is not allowed for card payments"}},
+		validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("1.00"),
+			Card: CreditCard{Name: "John Doe"}, Token: Token{Token: "abc"}}))
+
+	assert.Equal(t, []ErrorDetail{
+		{"payment_profile.customer_code", "is required"},
+		{"payment_profile.card_id", "must be 1 or more"}},
+		validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.PROFILE, Amount: MustParseMoney("1.00")}))
+
+	assert.Equal(t, []ErrorDetail{{"card", "is not allowed for cheque payments"}},
+		validationDetails(t, PaymentRequest{PaymentMethod: paymentMethods.CHEQUE, Amount: MustParseMoney("1.00"), Card: CreditCard{Number: "4030000010001234"}}))
+
+	assert.Equal(t, []ErrorDetail{{"payment_method", `"bitcoin" is not a payment method`}},
+		validationDetails(t, PaymentRequest{PaymentMethod: "bitcoin", Amount: MustParseMoney("1.00")}))
+}
+
+func TestUnit_Validate_Limits(t *testing.T) {
+	request := PaymentRequest{
+		PaymentMethod: paymentMethods.CASH,
+		OrderNumber:   strings.Repeat("A", 31) + "!",
+		Custom:        CustomFields{Ref3: strings.Repeat("x", 257)}}
+	assert.Equal(t, []ErrorDetail{
+		{"amount", "must be greater than 0"},
+		{"order_number", "must be at most 30 characters"},
+		{"order_number", `may only contain letters, digits and "-_./"`},
+		{"custom.ref3", "must be at most 256 characters"}},
+		validationDetails(t, request))
+
+	request = level3Request()
+	request.PaymentMethod = paymentMethods.CASH
+	request.Amount = MustParseMoney("1.00")
+	assert.Equal(t, []ErrorDetail{{"amount", "is 1.00 but the line items total 89.36"}}, validationDetails(t, request))
+}
@@ -0,0 +1,250 @@
+package beanstream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"strconv"
+	"strings"
+)
+
+// AvsMatch says whether one part of the billing address matched the card
+// issuer's records, as in PaymentResponse.Card.AddressMatch and PostalResult.
+type AvsMatch int
+
+const (
+	AvsNotMatched AvsMatch = 0 // did not match, or could not be checked
+	AvsMatched    AvsMatch = 1
+)
+
+func (m AvsMatch) String() string {
+	if m == AvsMatched {
+		return "matched"
+	}
+	return "not matched"
+}
+
+/*
+AvsResult is the address verification code the card issuer returned, such as
+"Y" for a street address and postal code that both matched. An empty result
+means the address was not verified.
+*/
+type AvsResult string
+
+type avsCode struct {
+	street, postal, verified bool
+	text                     string
+}
+
+var avsCodes = map[AvsResult]avsCode{
+	"":  {false, false, false, "not verified"},
+	"0": {false, false, false, "not verified"},
+	"Y": {true, true, true, "street and postal matched"},
+	"X": {true, true, true, "street and postal matched"},
+	"D": {true, true, true, "street and postal matched"},
+	"M": {true, true, true, "street and postal matched"},
+	"F": {true, true, true, "street and postal matched"},
+	"A": {true, false, true, "street matched, postal mismatch"},
+	"B": {true, false, true, "street matched, postal not verified"},
+	"Z": {false, true, true, "postal matched, street mismatch"},
+	"W": {false, true, true, "postal matched, street mismatch"},
+	"P": {false, true, true, "postal matched, street not verified"},
+	"N": {false, false, true, "street and postal mismatch"},
+	"C": {false, false, true, "street and postal mismatch"},
+	"U": {false, false, false, "not verified: issuer unavailable"},
+	"R": {false, false, false, "not verified: issuer unavailable, retry"},
+	"S": {false, false, false, "not verified: not supported by the issuer"},
+	"G": {false, false, false, "not verified: issuer outside the AVS network"},
+	"I": {false, false, false, "not verified: international address"},
+	"E": {false, false, false, "not verified: not allowed for this card"},
+}
+
+func (r AvsResult) code() (avsCode, bool) {
+	c, ok := avsCodes[AvsResult(strings.ToUpper(strings.TrimSpace(string(r))))]
+	return c, ok
+}
+
+// StreetMatched reports whether the street address matched.
+func (r AvsResult) StreetMatched() bool {
+	c, _ := r.code()
+	return c.street
+}
+
+// PostalMatched reports whether the postal or ZIP code matched.
+func (r AvsResult) PostalMatched() bool {
+	c, _ := r.code()
+	return c.postal
+}
+
+// Verified reports whether the issuer checked the address at all. A result
+// that is not verified is neither a match nor a mismatch.
+func (r AvsResult) Verified() bool {
+	c, _ := r.code()
+	return c.verified
+}
+
+// String describes the result, eg "street matched, postal mismatch".
+func (r AvsResult) String() string {
+	if c, ok := r.code(); ok {
+		return c.text
+	}
+	return fmt.Sprintf("unknown AVS result %q", string(r))
+}
+
+// CvdResult is the outcome of checking the card's CVD, the 3 or 4 digit code
+// printed on it.
+type CvdResult int
+
+const (
+	CvdNotChecked      CvdResult = 0 // no result was returned
+	CvdMatched         CvdResult = 1
+	CvdMismatch        CvdResult = 2
+	CvdNotVerified     CvdResult = 3
+	CvdShouldBePresent CvdResult = 4 // the card has a CVD but none was sent
+	CvdIssuerUnable    CvdResult = 5 // the issuer could not check it
+	CvdNotProvided     CvdResult = 6
+)
+
+func (r CvdResult) String() string {
+	switch r {
+	case CvdNotChecked:
+		return "CVD not checked"
+	case CvdMatched:
+		return "CVD matched"
+	case CvdMismatch:
+		return "CVD mismatch"
+	case CvdNotVerified:
+		return "CVD not verified"
+	case CvdShouldBePresent:
+		return "CVD should have been present"
+	case CvdIssuerUnable:
+		return "CVD not verified: issuer unable to check"
+	case CvdNotProvided:
+		return "CVD not provided"
+	}
+	return fmt.Sprintf("unknown CVD result %d", int(r))
+}
+
+// UnmarshalJSON reads the result from a JSON number or a quoted string. An
+// empty string or null is CvdNotChecked.
+func (r *CvdResult) UnmarshalJSON(data []byte) error {
+	text := string(bytes.TrimSpace(data))
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = strings.TrimSpace(unquoted)
+		if text == "" {
+			*r = CvdNotChecked
+			return nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return fmt.Errorf("beanstream: invalid CVD result %v", text)
+	}
+	*r = CvdResult(v)
+	return nil
+}
+
+/*
+VerificationPolicy decides which AVS and CVD results are too risky to keep a
+payment for. Set it as Gateway.Verification and MakePayment voids, with
+VoidPayment, any approved card, token or profile payment that fails it, then
+returns a *VerificationException instead of the response. A pre-authorization
+cannot be voided, so it is released by completing it for 0 instead:
+	gateway.Verification = beanstream.VerificationPolicy{
+		VoidOnPostalMismatch: true,
+		VoidOnCvdMismatch:    true}
+The zero policy voids nothing. A gateway or issuer that did not check the
+address reports it as not matched, so only require street or postal matches if
+every card you take is checked. Payments made from a profile usually carry no
+CVD; leave VoidOnCvdUnverified off for them.
+*/
+type VerificationPolicy struct {
+	VoidOnStreetMismatch bool // the street address did not match
+	VoidOnPostalMismatch bool // the postal or ZIP code did not match
+	VoidOnCvdMismatch    bool // the CVD was checked and did not match
+	VoidOnCvdUnverified  bool // the CVD was anything but matched
+
+	// Check, if set, is asked after the rules above pass. It returns why the
+	// payment should be voided, or "" to keep it.
+	Check func(res *PaymentResponse) string
+}
+
+func (p VerificationPolicy) enabled() bool {
+	return p.VoidOnStreetMismatch || p.VoidOnPostalMismatch || p.VoidOnCvdMismatch || p.VoidOnCvdUnverified || p.Check != nil
+}
+
+// Failures lists why res fails the policy, or nil if it passes.
+func (p VerificationPolicy) Failures(res *PaymentResponse) []string {
+	var failures []string
+	if p.VoidOnStreetMismatch && res.Card.AddressMatch != AvsMatched {
+		failures = append(failures, "street address not matched")
+	}
+	if p.VoidOnPostalMismatch && res.Card.PostalResult != AvsMatched {
+		failures = append(failures, "postal code not matched")
+	}
+	if p.VoidOnCvdUnverified && res.Card.CvdMatch != CvdMatched {
+		failures = append(failures, res.Card.CvdMatch.String())
+	} else if p.VoidOnCvdMismatch && res.Card.CvdMatch == CvdMismatch {
+		failures = append(failures, res.Card.CvdMatch.String())
+	}
+	if len(failures) == 0 && p.Check != nil {
+		if reason := p.Check(res); reason != "" {
+			failures = append(failures, reason)
+		}
+	}
+	return failures
+}
+
+/*
+VerificationException is returned by MakePayment when the gateway approved a
+payment that failed the Gateway's VerificationPolicy. Payment is the approved
+response and Void the response to voiding it, or to releasing it if it was a
+pre-authorization. If that failed, VoidErr says why and the payment still
+stands; void or release it yourself or contact support.
+*/
+type VerificationException struct {
+	Failures []string
+	Payment  *PaymentResponse
+	Void     *PaymentResponse
+	VoidErr  error
+}
+
+func (e *VerificationException) Error() string {
+	if e.VoidErr != nil {
+		return fmt.Sprintf("payment %v failed verification (%v) but could not be voided: %v", e.Payment.ID, strings.Join(e.Failures, ", "), e.VoidErr)
+	}
+	return fmt.Sprintf("payment %v failed verification (%v) and was voided", e.Payment.ID, strings.Join(e.Failures, ", "))
+}
+
+func (e *VerificationException) Unwrap() error {
+	return e.VoidErr
+}
+
+// verify voids res if it is an approved card payment that fails the policy,
+// or releases it if it is a pre-authorization.
+func (api PaymentsAPI) verify(ctx context.Context, request PaymentRequest, res *PaymentResponse) error {
+	if !api.verification.enabled() || !res.IsApproved() {
+		return nil
+	}
+	switch request.PaymentMethod {
+	case paymentMethods.CARD, paymentMethods.TOKEN, paymentMethods.PROFILE:
+	default:
+		return nil
+	}
+	failures := api.verification.Failures(res)
+	if len(failures) == 0 {
+		return nil
+	}
+	var void *PaymentResponse
+	var err error
+	if res.Type == TransactionPreAuth {
+		void, err = api.releasePreAuth(ctx, res.ID)
+	} else {
+		void, err = api.VoidPaymentContext(ctx, res.ID, request.Amount)
+	}
+	return &VerificationException{failures, res, void, err}
+}
@@ -0,0 +1,123 @@
+// +build unit integration
+
+package beanstream
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Beanstream/beanstream-go/paymentMethods"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestUnit_Verification_AvsResult(t *testing.T) {
+	assert.True(t, AvsResult("Y").StreetMatched())
+	assert.True(t, AvsResult("Y").PostalMatched())
+	assert.True(t, AvsResult("a").StreetMatched())
+	assert.False(t, AvsResult("A").PostalMatched())
+	assert.Equal(t, "postal matched, street mismatch", AvsResult("Z").String())
+	assert.True(t, AvsResult("N").Verified())
+	assert.False(t, AvsResult("U").Verified())
+	assert.False(t, AvsResult("").Verified())
+	assert.Equal(t, `unknown AVS result "Q"`, AvsResult("Q").String())
+}
+
+func TestUnit_Verification_CvdResultDecoded(t *testing.T) {
+	var card CreditCard
+	assert.Nil(t, json.Unmarshal([]byte(`{"avs_result":"Y","cvd_result":"2"}`), &card))
+	assert.Equal(t, CvdMismatch, card.CvdResult)
+	assert.Equal(t, "CVD mismatch", card.CvdResult.String())
+
+	var rec TransactionRecord
+	assert.Nil(t, json.Unmarshal([]byte(`{"trn_avs_result":"N","trn_cvd_result":1}`), &rec))
+	assert.Equal(t, CvdMatched, rec.CvdResult)
+	assert.Equal(t, "street and postal mismatch", rec.AvsResult.String())
+
+	var res PaymentResponse
+	assert.Nil(t, json.Unmarshal([]byte(`{"approved":"1","card":{"address_match":1,"cvd_match":3,"postal_result":0}}`), &res))
+	assert.Equal(t, AvsMatched, res.Card.AddressMatch)
+	assert.Equal(t, AvsNotMatched, res.Card.PostalResult)
+	assert.Equal(t, CvdNotVerified, res.Card.CvdMatch)
+}
+
+func TestUnit_Verification_Failures(t *testing.T) {
+	res := &PaymentResponse{}
+	res.Card.AddressMatch = AvsMatched
+	res.Card.CvdMatch = CvdNotVerified
+	assert.Nil(t, VerificationPolicy{}.Failures(res))
+	assert.Nil(t, VerificationPolicy{VoidOnStreetMismatch: true, VoidOnCvdMismatch: true}.Failures(res))
+	assert.Equal(t, []string{"postal code not matched", "CVD not verified"},
+		VerificationPolicy{VoidOnPostalMismatch: true, VoidOnCvdUnverified: true}.Failures(res))
+	custom := VerificationPolicy{Check: func(res *PaymentResponse) string { return "card type " + res.Card.CardType }}
+	res.Card.CardType = "AM"
+	assert.Equal(t, []string{"card type AM"}, custom.Failures(res))
+}
+
+// cvdMismatch is an approved purchase whose CVD did not match.
+const cvdMismatch = `{"id":"10000001","approved":"1","message_id":"1","type":"P","card":{"address_match":1,"postal_result":1,"cvd_match":2}}`
+
+func TestUnit_Verification_VoidsFailedPayment(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/void", 200, `{"id":"10000002","approved":"1","message_id":"1","type":"VP"}`).
+		on(http.MethodPost, "/payments", 200, cvdMismatch)
+	gateway := Gateway{
+		Config:       DefaultConfig(),
+		HTTPClient:   fake.client(),
+		Verification: VerificationPolicy{VoidOnCvdMismatch: true}}
+	request := PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("12.99")}
+	res, err := gateway.Payments().MakePayment(request)
+	assert.Nil(t, res)
+	var verr *VerificationException
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, []string{"CVD mismatch"}, verr.Failures)
+	assert.Equal(t, "10000001", verr.Payment.ID)
+	assert.Equal(t, "10000002", verr.Void.ID)
+	assert.Nil(t, verr.VoidErr)
+	assert.Equal(t, 1, fake.count(http.MethodPost, "/void"))
+	assert.Equal(t, `{"amount":12.99}`, fake.received()[1].Body)
+}
+
+func TestUnit_Verification_ReleasesPreAuth(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/void", 400, `{"code":208,"category":2,"message":"Invalid adjustment: the transaction cannot be changed this way"}`).
+		on(http.MethodPost, "/completions", 200, `{"id":"10000002","approved":"1","message_id":"1","type":"PAC","amount":0.00}`).
+		on(http.MethodPost, "/payments", 200, `{"id":"10000001","approved":"1","message_id":"1","type":"PA","card":{"address_match":1,"postal_result":1,"cvd_match":2}}`)
+	gateway := Gateway{
+		Config:       DefaultConfig(),
+		HTTPClient:   fake.client(),
+		Verification: VerificationPolicy{VoidOnCvdMismatch: true}}
+	request := PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("12.99")}
+	p, err := gateway.Payments().PreAuthorize(request)
+	var verr *VerificationException
+	assert.True(t, errors.As(err, &verr))
+	assert.Nil(t, verr.VoidErr)
+	assert.Equal(t, "10000002", verr.Void.ID)
+	assert.Equal(t, 0, fake.count(http.MethodPost, "/void"))
+	assert.Equal(t, 1, fake.count(http.MethodPost, "/payments/10000001/completions"))
+	assert.Contains(t, fake.received()[1].Body, `"amount":0.00`)
+
+	// the pre-auth is handed back already released
+	assert.NotNil(t, p)
+	assert.True(t, p.Released())
+}
+
+func TestUnit_Verification_KeepsPassingPayment(t *testing.T) {
+	fake := (&fakeGateway{}).
+		on(http.MethodPost, "/void", 200, `{"id":"10000002","approved":"1","message_id":"1","type":"VP"}`).
+		on(http.MethodPost, "/payments", 200, cvdMismatch)
+	gateway := Gateway{
+		Config:       DefaultConfig(),
+		HTTPClient:   fake.client(),
+		Verification: VerificationPolicy{VoidOnPostalMismatch: true}}
+	res, err := gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CARD, Amount: MustParseMoney("12.99")})
+	assert.Nil(t, err)
+	assert.Equal(t, "10000001", res.ID)
+
+	// cash payments carry no card results and are never voided
+	gateway.Verification = VerificationPolicy{VoidOnCvdMismatch: true}
+	res, err = gateway.Payments().MakePayment(PaymentRequest{PaymentMethod: paymentMethods.CASH, Amount: MustParseMoney("12.99")})
+	assert.Nil(t, err)
+	assert.NotNil(t, res)
+	assert.Equal(t, 0, fake.count(http.MethodPost, "/void"))
+}